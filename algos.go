@@ -0,0 +1,283 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	algosPath = "v1/api/iserver/contract/{conid}/algos"
+)
+
+// AlgoValueClass - type of an algo parameter value
+type AlgoValueClass string
+
+const (
+	AlgoString  AlgoValueClass = "String"
+	AlgoDouble  AlgoValueClass = "Double"
+	AlgoInteger AlgoValueClass = "Integer"
+	AlgoBoolean AlgoValueClass = "Boolean"
+	AlgoTime    AlgoValueClass = "Time"
+)
+
+// StrategyParameters - Parameters of an IB algo keyed by parameter ID
+type StrategyParameters map[string]interface{}
+
+/*
+AlgosInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1contract~1%7Bconid%7D~1algos/get
+*/
+type AlgosInput struct {
+	ConID          string
+	Algos          []string
+	AddDescription bool
+	AddParams      bool
+}
+
+func (a AlgosInput) toQuery() []query {
+	var queries []query
+
+	if len(a.Algos) > 0 {
+		queries = append(queries, query{
+			key:   "algos",
+			value: strings.Join(a.Algos, ";"),
+		})
+	}
+
+	if a.AddDescription {
+		queries = append(queries, query{
+			key:   "addDescription",
+			value: "1",
+		})
+	}
+
+	if a.AddParams {
+		queries = append(queries, query{
+			key:   "addParams",
+			value: "1",
+		})
+	}
+
+	return queries
+}
+
+/*
+Algos - Algos available for a contract
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1contract~1%7Bconid%7D~1algos/get
+*/
+type Algos struct {
+	Algos []Algo `json:"algos"`
+}
+
+// Algo - Algo strategy and the schema of its parameters
+type Algo struct {
+	Name       string          `json:"name"`
+	ID         string          `json:"id"`
+	Parameters []AlgoParameter `json:"parameters"`
+}
+
+// AlgoParameter - Schema of an algo parameter
+type AlgoParameter struct {
+	ID                string         `json:"id"`
+	Name              string         `json:"name"`
+	ValueClassName    AlgoValueClass `json:"valueClassName"`
+	DefaultValue      interface{}    `json:"defaultValue"`
+	MinValue          *float64       `json:"minValue"`
+	MaxValue          *float64       `json:"maxValue"`
+	LegalStrings      []string       `json:"legalStrings"`
+	Required          bool           `json:"required"`
+	Description       string         `json:"description"`
+	GuiRank           int            `json:"guiRank"`
+	EnabledConditions []string       `json:"enabledConditions"`
+}
+
+func (a *AlgoParameter) UnmarshalJSON(v []byte) error {
+	// required is sent as a string by the gateway
+	type altParameter AlgoParameter
+	type altStruct struct {
+		altParameter
+		Required string `json:"required"`
+	}
+
+	var alt altStruct
+	if err := json.Unmarshal(v, &alt); err == nil {
+		*a = AlgoParameter(alt.altParameter)
+		a.Required = alt.Required == "true"
+		return nil
+	}
+
+	var inner altParameter
+	if err := json.Unmarshal(v, &inner); err != nil {
+		return errors.Wrap(err, "failed to find appropriate struct to unmarshal algo parameter into")
+	}
+
+	*a = AlgoParameter(inner)
+	return nil
+}
+
+// Algo - gets an algo by ID
+func (a Algos) Algo(id string) (Algo, bool) {
+	for _, algo := range a.Algos {
+		if strings.EqualFold(algo.ID, id) {
+			return algo, true
+		}
+	}
+
+	return Algo{}, false
+}
+
+/*
+Validate - Checks the parameters against the algo schema: every parameter must
+be known, required parameters set, and values of the right type within range or
+one of the legal strings.
+*/
+func (a Algo) Validate(params StrategyParameters) error {
+	schema := map[string]AlgoParameter{}
+	for _, p := range a.Parameters {
+		schema[p.ID] = p
+	}
+
+	ids := make([]string, 0, len(params))
+	for id := range params {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		p, ok := schema[id]
+		if !ok {
+			return errors.Errorf("unknown parameter '%s' for algo '%s'", id, a.ID)
+		}
+
+		if err := p.validate(params[id]); err != nil {
+			return errors.Wrapf(err, "invalid parameter '%s' for algo '%s'", id, a.ID)
+		}
+	}
+
+	for _, p := range a.Parameters {
+		if _, ok := params[p.ID]; p.Required && !ok {
+			return errors.Errorf("missing required parameter '%s' for algo '%s'", p.ID, a.ID)
+		}
+	}
+
+	return nil
+}
+
+// Apply - validates the parameters and returns a copy of the order using the algo
+func (a Algo) Apply(order Order, params StrategyParameters) (Order, error) {
+	if err := a.Validate(params); err != nil {
+		return Order{}, err
+	}
+
+	order.Strategy = a.ID
+	order.StrategyParameters = params
+	return order, nil
+}
+
+func (a AlgoParameter) validate(value interface{}) error {
+	switch a.ValueClassName {
+	case AlgoDouble, AlgoInteger:
+		f, err := algoNumber(value)
+		if err != nil {
+			return err
+		}
+
+		if a.ValueClassName == AlgoInteger && f != float64(int64(f)) {
+			return errors.Errorf("value %v is not an integer", value)
+		}
+
+		if a.MinValue != nil && f < *a.MinValue {
+			return errors.Errorf("value %v is below minimum %v", value, *a.MinValue)
+		}
+
+		if a.MaxValue != nil && f > *a.MaxValue {
+			return errors.Errorf("value %v is above maximum %v", value, *a.MaxValue)
+		}
+	case AlgoBoolean:
+		switch v := value.(type) {
+		case bool:
+		case string:
+			if _, err := strconv.ParseBool(v); err != nil {
+				return errors.Errorf("value '%s' is not a boolean", v)
+			}
+		default:
+			return errors.Errorf("value %v is not a boolean", value)
+		}
+	default:
+		s, ok := value.(string)
+		if !ok {
+			return errors.Errorf("value %v is not a string", value)
+		}
+
+		if len(a.LegalStrings) == 0 {
+			return nil
+		}
+
+		for _, legal := range a.LegalStrings {
+			if s == legal {
+				return nil
+			}
+		}
+
+		return errors.Errorf("value '%s' is not one of %s", s, strings.Join(a.LegalStrings, ", "))
+	}
+
+	return nil
+}
+
+func algoNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0, errors.Errorf("value '%s' is not a number", v)
+		}
+		return f, nil
+	default:
+		return 0, errors.Errorf("value %v is not a number", value)
+	}
+}
+
+/*
+Algos - Gets the algos available for a contract and the schema of their parameters
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1contract~1%7Bconid%7D~1algos/get
+*/
+func (c *client) Algos(input AlgosInput) (*Algos, error) {
+	resp, err := c.get(substituteParam(algosPath, param{
+		key:   "conid",
+		value: input.ConID,
+	}), input.toQuery()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var algos Algos
+	if err := json.Unmarshal(v, &algos); err != nil {
+		return nil, err
+	}
+
+	return &algos, nil
+}
@@ -0,0 +1,196 @@
+package ibweb
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlgosIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	algos, err := c.Algos(AlgosInput{ConID: "265598", AddParams: true})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Greater(t, len(algos.Algos), 0)
+}
+
+func TestAlgosUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get algos")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get algos",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read algos")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read algos",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/algos.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, "http://127.0.0.1:5555/v1/api/iserver/contract/265598/algos", tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.Algos(AlgosInput{ConID: "265598"})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestAlgoValidateUnit(t *testing.T) {
+	v, err := os.ReadFile("./testdata/algos.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var algos Algos
+	if !assert.Nil(t, json.Unmarshal(v, &algos)) {
+		t.FailNow()
+	}
+
+	adaptive, ok := algos.Algo("adaptive")
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.True(t, adaptive.Parameters[0].Required)
+
+	vwap, ok := algos.Algo("Vwap")
+	if !assert.True(t, ok) {
+		t.FailNow()
+	}
+	assert.False(t, vwap.Parameters[0].Required)
+
+	tests := []struct {
+		name            string
+		algo            Algo
+		params          StrategyParameters
+		wantErrContains string
+	}{
+		{"accepts legal string", adaptive, StrategyParameters{"adaptivePriority": "Urgent"}, ""},
+		{"rejects illegal string", adaptive, StrategyParameters{"adaptivePriority": "Fast"}, "is not one of"},
+		{"rejects missing required", adaptive, StrategyParameters{}, "missing required parameter 'adaptivePriority'"},
+		{"rejects unknown parameter", adaptive, StrategyParameters{"adaptivePriority": "Normal", "foo": 1}, "unknown parameter 'foo'"},
+		{
+			"accepts vwap parameters",
+			vwap,
+			StrategyParameters{"maxPctVol": 0.1, "noTakeLiq": true, "startTime": "09:30:00 US/Eastern"},
+			"",
+		},
+		{"accepts numeric string", vwap, StrategyParameters{"maxPctVol": "10"}, ""},
+		{"rejects below minimum", vwap, StrategyParameters{"maxPctVol": 0.001}, "below minimum"},
+		{"rejects above maximum", vwap, StrategyParameters{"maxPctVol": 51}, "above maximum"},
+		{"rejects non number", vwap, StrategyParameters{"maxPctVol": "lots"}, "is not a number"},
+		{"rejects non boolean", vwap, StrategyParameters{"noTakeLiq": "maybe"}, "is not a boolean"},
+		{"rejects non string time", vwap, StrategyParameters{"startTime": 930}, "is not a string"},
+	}
+
+	for _, tc := range tests {
+		err := tc.algo.Validate(tc.params)
+		if !assertError(t, tc.wantErrContains != "", tc.wantErrContains, err) {
+			t.Log(tc.name)
+		}
+	}
+
+	order, err := adaptive.Apply(Order{Conid: 265598}, StrategyParameters{"adaptivePriority": "Patient"})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	encoded, err := json.Marshal(order)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"conid":265598,"strategy":"Adaptive","strategyParameters":{"adaptivePriority":"Patient"}}`, string(encoded))
+
+	encoded, err = json.Marshal(Order{Conid: 265598})
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"conid":265598}`, string(encoded))
+}
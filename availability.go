@@ -0,0 +1,234 @@
+package ibweb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AvailabilityType - Whether market data is real time, delayed or frozen
+type AvailabilityType int
+
+const (
+	AvailabilityUnknown AvailabilityType = iota
+	AvailabilityRealtime
+	AvailabilityStreaming
+	AvailabilityDelayed
+	AvailabilityFrozen
+	AvailabilityFrozenDelayed
+	AvailabilityNotSubscribed
+)
+
+func (a AvailabilityType) String() string {
+	switch a {
+	case AvailabilityRealtime:
+		return "realtime"
+	case AvailabilityStreaming:
+		return "streaming"
+	case AvailabilityDelayed:
+		return "delayed"
+	case AvailabilityFrozen:
+		return "frozen"
+	case AvailabilityFrozenDelayed:
+		return "frozen delayed"
+	case AvailabilityNotSubscribed:
+		return "not subscribed"
+	default:
+		return "unknown"
+	}
+}
+
+/*
+MarketDataAvailability - Decoded market data availability code, e.g. RpB. The
+first character is the type, followed by flags: P snapshot, p consolidated, B
+book, i incomplete and v VDR exempt. Delay is set from the delay history
+responses report next to the code.
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1iserver~1marketdata~1snapshot/get
+*/
+type MarketDataAvailability struct {
+	Code string
+	Type AvailabilityType
+	// Snapshot - data is a snapshot rather than a market data subscription
+	Snapshot     bool
+	Consolidated bool
+	Book         bool
+	Incomplete   bool
+	VDRExempt    bool
+	// Delay - delay of the data, zero when real time or not reported
+	Delay time.Duration
+}
+
+// ParseMarketDataAvailability - decodes an availability code, unknown characters are ignored
+func ParseMarketDataAvailability(code string) MarketDataAvailability {
+	a := MarketDataAvailability{Code: code}
+
+	for i, ch := range code {
+		if i == 0 {
+			switch ch {
+			case 'R':
+				a.Type = AvailabilityRealtime
+				continue
+			case 'S':
+				a.Type = AvailabilityStreaming
+				continue
+			case 'D':
+				a.Type = AvailabilityDelayed
+				continue
+			case 'Z':
+				a.Type = AvailabilityFrozen
+				continue
+			case 'Y':
+				a.Type = AvailabilityFrozenDelayed
+				continue
+			case 'N':
+				a.Type = AvailabilityNotSubscribed
+				continue
+			}
+		}
+
+		switch ch {
+		case 'P':
+			a.Snapshot = true
+		case 'p':
+			a.Consolidated = true
+		case 'B':
+			a.Book = true
+		case 'i':
+			a.Incomplete = true
+		case 'v':
+			a.VDRExempt = true
+		}
+	}
+
+	return a
+}
+
+// IsDelayed - the data is delayed, including frozen delayed data and data reported with a delay
+func (m MarketDataAvailability) IsDelayed() bool {
+	return m.Type == AvailabilityDelayed || m.Type == AvailabilityFrozenDelayed || m.Delay > 0
+}
+
+// IsRealtime - the data is real time or streaming
+func (m MarketDataAvailability) IsRealtime() bool {
+	return m.Type == AvailabilityRealtime || m.Type == AvailabilityStreaming
+}
+
+func (m MarketDataAvailability) String() string {
+	var flags []string
+	if m.Snapshot {
+		flags = append(flags, "snapshot")
+	}
+	if m.Consolidated {
+		flags = append(flags, "consolidated")
+	}
+	if m.Book {
+		flags = append(flags, "book")
+	}
+	if m.Incomplete {
+		flags = append(flags, "incomplete")
+	}
+	if m.Delay > 0 {
+		flags = append(flags, fmt.Sprintf("%s delay", m.Delay))
+	}
+
+	if len(flags) == 0 {
+		return m.Type.String()
+	}
+
+	return fmt.Sprintf("%s (%s)", m.Type, strings.Join(flags, ", "))
+}
+
+// Availability - gets the decoded MdAvailability with the MktDataDelay in seconds
+func (m MarketDataHistory) Availability() MarketDataAvailability {
+	availability := ParseMarketDataAvailability(m.MdAvailability)
+	availability.Delay = time.Duration(m.MktDataDelay) * time.Second
+
+	return availability
+}
+
+// Availability - gets the decoded FieldMarketDataAvailability, unknown when it was not requested
+func (s Snapshot) Availability() MarketDataAvailability {
+	return ParseMarketDataAvailability(s.Get(FieldMarketDataAvailability).Raw)
+}
+
+// MarketDataOperation - Client operation returning market data
+type MarketDataOperation string
+
+const (
+	OperationMarketDataHistory  MarketDataOperation = "MarketDataHistory"
+	OperationMarketDataSnapshot MarketDataOperation = "MarketDataSnapshot"
+	OperationHMDSHistory        MarketDataOperation = "HMDSHistory"
+)
+
+// DelayedDataAction - What the client does when an operation returns delayed data
+type DelayedDataAction int
+
+const (
+	DelayedDataAllow DelayedDataAction = iota
+	DelayedDataWarn
+	DelayedDataReject
+)
+
+/*
+DelayedDataPolicy - Policy applied by the client to delayed market data. While the
+policy applies to snapshots FieldMarketDataAvailability is added to every snapshot
+request.
+*/
+type DelayedDataPolicy struct {
+	Action DelayedDataAction
+	// Operations - operations the policy applies to, empty applies to all
+	Operations []MarketDataOperation
+	// Warn - called when Action is DelayedDataWarn, nil ignores the delayed data
+	Warn func(err DelayedDataError)
+}
+
+// DelayedDataError - Delayed data returned by an operation
+type DelayedDataError struct {
+	Operation    MarketDataOperation
+	Conid        int
+	Availability MarketDataAvailability
+}
+
+func (d DelayedDataError) Error() string {
+	if d.Conid == 0 {
+		return fmt.Sprintf("%s returned %s market data", d.Operation, d.Availability)
+	}
+
+	return fmt.Sprintf("%s returned %s market data for conid '%d'", d.Operation, d.Availability, d.Conid)
+}
+
+func (d DelayedDataPolicy) appliesTo(op MarketDataOperation) bool {
+	if d.Action == DelayedDataAllow {
+		return false
+	}
+
+	if len(d.Operations) == 0 {
+		return true
+	}
+
+	for _, o := range d.Operations {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check - applies the policy to data returned by op, returning an error when rejected
+func (d DelayedDataPolicy) check(op MarketDataOperation, conid int, availability MarketDataAvailability) error {
+	if !availability.IsDelayed() || !d.appliesTo(op) {
+		return nil
+	}
+
+	err := DelayedDataError{Operation: op, Conid: conid, Availability: availability}
+	if d.Action == DelayedDataReject {
+		return err
+	}
+
+	if d.Warn != nil {
+		d.Warn(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package ibweb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMarketDataAvailabilityUnit(t *testing.T) {
+	tests := []struct {
+		code string
+		want MarketDataAvailability
+	}{
+		{"", MarketDataAvailability{}},
+		{"S", MarketDataAvailability{Code: "S", Type: AvailabilityStreaming}},
+		{"RpB", MarketDataAvailability{Code: "RpB", Type: AvailabilityRealtime, Consolidated: true, Book: true}},
+		{"DPB", MarketDataAvailability{Code: "DPB", Type: AvailabilityDelayed, Snapshot: true, Book: true}},
+		{"Zi", MarketDataAvailability{Code: "Zi", Type: AvailabilityFrozen, Incomplete: true}},
+		{"Y", MarketDataAvailability{Code: "Y", Type: AvailabilityFrozenDelayed}},
+		{"N", MarketDataAvailability{Code: "N", Type: AvailabilityNotSubscribed}},
+		{"Pv", MarketDataAvailability{Code: "Pv", Snapshot: true, VDRExempt: true}},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, ParseMarketDataAvailability(tc.code), tc.code)
+	}
+
+	assert.True(t, ParseMarketDataAvailability("D").IsDelayed())
+	assert.True(t, ParseMarketDataAvailability("Y").IsDelayed())
+	assert.False(t, ParseMarketDataAvailability("Z").IsDelayed())
+	assert.True(t, ParseMarketDataAvailability("S").IsRealtime())
+	assert.Equal(t, "delayed (snapshot, book)", ParseMarketDataAvailability("DPB").String())
+}
+
+func TestMarketDataHistoryAvailabilityUnit(t *testing.T) {
+	history := MarketDataHistory{MdAvailability: "S"}
+	assert.Equal(t, AvailabilityStreaming, history.Availability().Type)
+	assert.False(t, history.Availability().IsDelayed())
+
+	history.MktDataDelay = 900
+	assert.Equal(t, 15*time.Minute, history.Availability().Delay)
+	assert.True(t, history.Availability().IsDelayed())
+	assert.Equal(t, "streaming (15m0s delay)", history.Availability().String())
+
+	snapshot := Snapshot{Fields: map[SnapshotField]SnapshotValue{
+		FieldMarketDataAvailability: {Raw: "DP", Available: true},
+	}}
+	assert.Equal(t, AvailabilityDelayed, snapshot.Availability().Type)
+	assert.Equal(t, AvailabilityUnknown, Snapshot{}.Availability().Type)
+}
+
+func TestDelayedDataPolicyUnit(t *testing.T) {
+	var warned []DelayedDataError
+
+	tests := []struct {
+		name            string
+		policy          DelayedDataPolicy
+		wantErrContains string
+		wantWarnings    int
+	}{
+		{
+			"allows delayed data by default",
+			DelayedDataPolicy{},
+			"",
+			0,
+		},
+		{
+			"rejects delayed data",
+			DelayedDataPolicy{Action: DelayedDataReject},
+			"MarketDataSnapshot returned delayed (snapshot, book) market data for conid '265598'",
+			0,
+		},
+		{
+			"rejects delayed data of listed operations",
+			DelayedDataPolicy{Action: DelayedDataReject, Operations: []MarketDataOperation{OperationMarketDataSnapshot}},
+			"returned delayed",
+			0,
+		},
+		{
+			"ignores operations not listed",
+			DelayedDataPolicy{Action: DelayedDataReject, Operations: []MarketDataOperation{OperationMarketDataHistory}},
+			"",
+			0,
+		},
+		{
+			"warns on delayed data",
+			DelayedDataPolicy{Action: DelayedDataWarn, Warn: func(err DelayedDataError) { warned = append(warned, err) }},
+			"",
+			1,
+		},
+	}
+
+	v, err := os.ReadFile("./testdata/market_data_snapshot.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = io.ReadAll
+		warned = nil
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataSnapshot),
+			httpmock.NewBytesResponder(200, v))
+
+		c := New("http://127.0.0.1:5555")
+		c.SetDelayedDataPolicy(tc.policy)
+		_, err := c.MarketDataSnapshot(MarketDataSnapshotInput{Conids: []int{265598}})
+		assertError(t, tc.wantErrContains != "", tc.wantErrContains, err)
+		assert.Len(t, warned, tc.wantWarnings, tc.name)
+		if err != nil {
+			assert.IsType(t, DelayedDataError{}, err, tc.name)
+		}
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestDelayedDataPolicyHistoryUnit(t *testing.T) {
+	policy := DelayedDataPolicy{Action: DelayedDataReject}
+
+	assert.Nil(t, policy.check(OperationMarketDataHistory, 265598, ParseMarketDataAvailability("S")))
+	assert.Nil(t, policy.check(OperationMarketDataHistory, 265598, ParseMarketDataAvailability("")))
+	assert.EqualError(t, policy.check(OperationMarketDataHistory, 265598, ParseMarketDataAvailability("D")),
+		"MarketDataHistory returned delayed market data for conid '265598'")
+}
+
+func TestDelayedDataPolicyHistoryDelayUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	v, err := os.ReadFile("./testdata/market_data_history.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataHistory),
+		httpmock.NewBytesResponder(200, v))
+
+	c := New("http://127.0.0.1:5555")
+	_, err = c.MarketDataHistory(MarketDataHistoryInput{ConID: "265598", Period: "1d", Bar: "1min"})
+	assert.Nil(t, err)
+
+	c.SetDelayedDataPolicy(DelayedDataPolicy{Action: DelayedDataReject})
+	_, err = c.MarketDataHistory(MarketDataHistoryInput{ConID: "265598", Period: "1d", Bar: "1min"})
+	assert.EqualError(t, err, "MarketDataHistory returned streaming (15m0s delay) market data for conid '265598'")
+}
+
+func TestDelayedDataPolicySnapshotFieldsUnit(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     DelayedDataPolicy
+		fields     []SnapshotField
+		wantFields string
+		wantErr    bool
+	}{
+		{
+			"leaves fields alone by default",
+			DelayedDataPolicy{},
+			[]SnapshotField{FieldLast},
+			"31",
+			false,
+		},
+		{
+			"adds the availability field",
+			DelayedDataPolicy{Action: DelayedDataReject},
+			[]SnapshotField{FieldLast},
+			"31,6509",
+			true,
+		},
+		{
+			"keeps a requested availability field",
+			DelayedDataPolicy{Action: DelayedDataReject},
+			[]SnapshotField{FieldMarketDataAvailability, FieldLast},
+			"6509,31",
+			true,
+		},
+		{
+			"warns without a callback",
+			DelayedDataPolicy{Action: DelayedDataWarn},
+			nil,
+			"6509",
+			false,
+		},
+	}
+
+	v, err := os.ReadFile("./testdata/market_data_snapshot.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = io.ReadAll
+
+		var gotFields string
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataSnapshot),
+			func(req *http.Request) (*http.Response, error) {
+				gotFields = req.URL.Query().Get("fields")
+				return httpmock.NewBytesResponse(200, v), nil
+			})
+
+		c := New("http://127.0.0.1:5555")
+		c.SetDelayedDataPolicy(tc.policy)
+		fields := append([]SnapshotField(nil), tc.fields...)
+		_, err := c.Snapshot([]int{265598}, fields)
+		assert.Equal(t, tc.wantErr, err != nil, tc.name)
+		assert.Equal(t, tc.wantFields, gotFields, tc.name)
+		assert.Equal(t, tc.fields, fields, tc.name)
+
+		httpmock.DeactivateAndReset()
+	}
+}
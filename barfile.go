@@ -0,0 +1,540 @@
+package ibweb
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/types"
+	"github.com/xitongsys/parquet-go/writer"
+)
+
+// BarColumn - Column of an exported bar series
+type BarColumn string
+
+const (
+	ColumnTime   BarColumn = "time"
+	ColumnOpen   BarColumn = "open"
+	ColumnHigh   BarColumn = "high"
+	ColumnLow    BarColumn = "low"
+	ColumnClose  BarColumn = "close"
+	ColumnVolume BarColumn = "volume"
+	ColumnVWAP   BarColumn = "vwap"
+)
+
+// DefaultBarColumns - columns written when CSVOptions.Columns is empty
+var DefaultBarColumns = []BarColumn{ColumnTime, ColumnOpen, ColumnHigh, ColumnLow, ColumnClose, ColumnVolume}
+
+const (
+	// TimeUnix - CSVOptions.TimeFormat writing epoch seconds
+	TimeUnix = "unix"
+	// TimeUnixMilli - CSVOptions.TimeFormat writing epoch milliseconds
+	TimeUnixMilli = "unixms"
+)
+
+/*
+CSVOptions - Layout of a CSV bar series. TimeFormat is a time layout, TimeUnix or
+TimeUnixMilli, empty uses RFC 3339. Times are written and read in Location, nil
+uses UTC.
+*/
+type CSVOptions struct {
+	Columns    []BarColumn
+	TimeFormat string
+	Location   *time.Location
+}
+
+func (o CSVOptions) columns() []BarColumn {
+	if len(o.Columns) == 0 {
+		return DefaultBarColumns
+	}
+
+	return o.Columns
+}
+
+func (o CSVOptions) location() *time.Location {
+	if o.Location == nil {
+		return time.UTC
+	}
+
+	return o.Location
+}
+
+func (o CSVOptions) formatTime(t time.Time) string {
+	switch o.TimeFormat {
+	case TimeUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeUnixMilli:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	case "":
+		return t.In(o.location()).Format(time.RFC3339)
+	default:
+		return t.In(o.location()).Format(o.TimeFormat)
+	}
+}
+
+func (o CSVOptions) parseTime(s string) (time.Time, error) {
+	switch o.TimeFormat {
+	case TimeUnix, TimeUnixMilli:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		if o.TimeFormat == TimeUnix {
+			return time.Unix(v, 0).In(o.location()), nil
+		}
+		return time.UnixMilli(v).In(o.location()), nil
+	case "":
+		t, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.In(o.location()), nil
+	default:
+		return time.ParseInLocation(o.TimeFormat, s, o.location())
+	}
+}
+
+// WriteBarsCSV - writes bars as CSV with a header row of the columns
+func WriteBarsCSV(w io.Writer, bars []Bar, opts CSVOptions) error {
+	columns := opts.columns()
+
+	cw := csv.NewWriter(w)
+	header := make([]string, len(columns))
+	for i, column := range columns {
+		header[i] = string(column)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, bar := range bars {
+		for i, column := range columns {
+			switch column {
+			case ColumnTime:
+				record[i] = opts.formatTime(bar.Time)
+			case ColumnOpen:
+				record[i] = formatBarFloat(bar.Open)
+			case ColumnHigh:
+				record[i] = formatBarFloat(bar.High)
+			case ColumnLow:
+				record[i] = formatBarFloat(bar.Low)
+			case ColumnClose:
+				record[i] = formatBarFloat(bar.Close)
+			case ColumnVolume:
+				record[i] = formatBarFloat(bar.Volume)
+			case ColumnVWAP:
+				record[i] = formatBarFloat(bar.VWAP)
+			default:
+				return errors.Errorf("unknown bar column '%s'", column)
+			}
+		}
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatBarFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+/*
+ReadBarsCSV - reads bars written by WriteBarsCSV. Columns are matched by the
+header row so opts.Columns is ignored, unknown columns are skipped and missing
+columns left zero.
+*/
+func ReadBarsCSV(r io.Reader, opts CSVOptions) ([]Bar, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bar header")
+	}
+
+	columns := make([]BarColumn, len(header))
+	for i, name := range header {
+		columns[i] = BarColumn(strings.ToLower(strings.TrimSpace(name)))
+	}
+
+	var bars []Bar
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read bar line %d", line)
+		}
+
+		var bar Bar
+		for i, column := range columns {
+			value := strings.TrimSpace(record[i])
+
+			var field *float64
+			switch column {
+			case ColumnTime:
+				if bar.Time, err = opts.parseTime(value); err != nil {
+					return nil, errors.Wrapf(err, "invalid time on bar line %d", line)
+				}
+				continue
+			case ColumnOpen:
+				field = &bar.Open
+			case ColumnHigh:
+				field = &bar.High
+			case ColumnLow:
+				field = &bar.Low
+			case ColumnClose:
+				field = &bar.Close
+			case ColumnVolume:
+				field = &bar.Volume
+			case ColumnVWAP:
+				field = &bar.VWAP
+			default:
+				continue
+			}
+
+			if value == "" {
+				continue
+			}
+
+			if *field, err = strconv.ParseFloat(value, 64); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s on bar line %d", column, line)
+			}
+		}
+
+		bars = append(bars, bar)
+	}
+
+	return bars, nil
+}
+
+// WriteBarsJSONL - writes bars as JSON lines
+func WriteBarsJSONL(w io.Writer, bars []Bar) error {
+	enc := json.NewEncoder(w)
+	for _, bar := range bars {
+		if err := enc.Encode(bar); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReadBarsJSONL - reads bars written by WriteBarsJSONL, blank lines are skipped
+func ReadBarsJSONL(r io.Reader) ([]Bar, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var bars []Bar
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var bar Bar
+		if err := json.Unmarshal(scanner.Bytes(), &bar); err != nil {
+			return nil, errors.Wrapf(err, "failed to read bar line %d", line)
+		}
+
+		bars = append(bars, bar)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return bars, nil
+}
+
+// parquetBarColumns - schema of the columns written by WriteBarsParquet
+var parquetBarColumns = map[BarColumn]string{
+	ColumnTime:   "name=time, type=INT64, convertedtype=TIMESTAMP_MILLIS, isadjustedtoutc=true",
+	ColumnOpen:   "name=open, type=DOUBLE",
+	ColumnHigh:   "name=high, type=DOUBLE",
+	ColumnLow:    "name=low, type=DOUBLE",
+	ColumnClose:  "name=close, type=DOUBLE",
+	ColumnVolume: "name=volume, type=DOUBLE",
+	ColumnVWAP:   "name=vwap, type=DOUBLE",
+}
+
+/*
+WriteBarsParquet - writes bars as a Snappy compressed Parquet file, nil columns
+uses DefaultBarColumns. Times are written as UTC timestamps in milliseconds.
+*/
+func WriteBarsParquet(w io.Writer, bars []Bar, columns []BarColumn) error {
+	if len(columns) == 0 {
+		columns = DefaultBarColumns
+	}
+
+	schema := make([]string, len(columns))
+	for i, column := range columns {
+		tag, ok := parquetBarColumns[column]
+		if !ok {
+			return errors.Errorf("unknown bar column '%s'", column)
+		}
+		schema[i] = tag
+	}
+
+	pw, err := writer.NewCSVWriterFromWriter(schema, w, 1)
+	if err != nil {
+		return errors.Wrap(err, "failed to create bar parquet writer")
+	}
+
+	for _, bar := range bars {
+		record := make([]interface{}, len(columns))
+		for i, column := range columns {
+			switch column {
+			case ColumnTime:
+				record[i] = bar.Time.UnixMilli()
+			case ColumnOpen:
+				record[i] = bar.Open
+			case ColumnHigh:
+				record[i] = bar.High
+			case ColumnLow:
+				record[i] = bar.Low
+			case ColumnClose:
+				record[i] = bar.Close
+			case ColumnVolume:
+				record[i] = bar.Volume
+			case ColumnVWAP:
+				record[i] = bar.VWAP
+			}
+		}
+
+		if err := pw.Write(record); err != nil {
+			return errors.Wrap(err, "failed to write bar parquet")
+		}
+	}
+
+	if err := pw.WriteStop(); err != nil {
+		return errors.Wrap(err, "failed to write bar parquet")
+	}
+
+	return nil
+}
+
+/*
+ReadBarsParquet - reads bars from a Parquet file of size bytes, such as one
+written by WriteBarsParquet, pandas or DuckDB. Columns are matched by name like
+ReadBarsCSV and must be flat. Times are read in UTC from timestamp columns, other
+integer time columns are taken as epoch milliseconds. Nulls are left zero.
+*/
+func ReadBarsParquet(r io.ReaderAt, size int64) ([]Bar, error) {
+	v, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := buffer.NewBufferFile(v)
+	if err != nil {
+		return nil, err
+	}
+
+	pr, err := reader.NewParquetColumnReader(file, 1)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read bar parquet")
+	}
+	defer pr.ReadStop()
+
+	rows := pr.GetNumRows()
+	bars := make([]Bar, rows)
+	if rows == 0 {
+		return bars, nil
+	}
+
+	for _, path := range pr.SchemaHandler.ValueColumns {
+		exPath := common.StrToPath(pr.SchemaHandler.InPathToExPath[path])
+		column := BarColumn(strings.ToLower(exPath[len(exPath)-1]))
+		if _, ok := parquetBarColumns[column]; !ok {
+			continue
+		}
+		if len(exPath) != 2 {
+			return nil, errors.Errorf("nested bar column '%s' is not supported", strings.Join(exPath[1:], "."))
+		}
+
+		values, _, _, err := pr.ReadColumnByPath(path, rows)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read bar column '%s'", column)
+		}
+		if int64(len(values)) != rows {
+			return nil, errors.Errorf("bar column '%s' has %d values instead of %d", column, len(values), rows)
+		}
+
+		element := pr.SchemaHandler.SchemaElements[pr.SchemaHandler.MapIndex[path]]
+		for i, value := range values {
+			if value == nil {
+				continue
+			}
+
+			if column == ColumnTime {
+				if bars[i].Time, err = parquetTime(element, value); err != nil {
+					return nil, errors.Wrapf(err, "invalid time on bar row %d", i)
+				}
+				continue
+			}
+
+			f, err := parquetFloat(element, value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s on bar row %d", column, i)
+			}
+
+			switch column {
+			case ColumnOpen:
+				bars[i].Open = f
+			case ColumnHigh:
+				bars[i].High = f
+			case ColumnLow:
+				bars[i].Low = f
+			case ColumnClose:
+				bars[i].Close = f
+			case ColumnVolume:
+				bars[i].Volume = f
+			case ColumnVWAP:
+				bars[i].VWAP = f
+			}
+		}
+	}
+
+	return bars, nil
+}
+
+// parquetTime - converts a timestamp value of element to UTC, unannotated integers are epoch milliseconds
+func parquetTime(element *parquet.SchemaElement, value interface{}) (time.Time, error) {
+	unit := time.Millisecond
+	if element.IsSetLogicalType() && element.LogicalType.IsSetTIMESTAMP() {
+		switch timestamp := element.LogicalType.TIMESTAMP; {
+		case timestamp.Unit.IsSetMICROS():
+			unit = time.Microsecond
+		case timestamp.Unit.IsSetNANOS():
+			unit = time.Nanosecond
+		}
+	} else if element.IsSetConvertedType() && element.GetConvertedType() == parquet.ConvertedType_TIMESTAMP_MICROS {
+		unit = time.Microsecond
+	}
+
+	switch v := value.(type) {
+	case int64:
+		return time.Unix(0, 0).Add(time.Duration(v) * unit).UTC(), nil
+	case int32:
+		return time.Unix(0, 0).Add(time.Duration(v) * unit).UTC(), nil
+	case string:
+		// INT96 timestamps of older writers such as Spark and Impala
+		if element.GetType() == parquet.Type_INT96 {
+			return types.INT96ToTime(v).UTC(), nil
+		}
+	}
+
+	return time.Time{}, errors.Errorf("unsupported time value of type %T", value)
+}
+
+// parquetFloat - converts a numeric value of element, decimals are scaled
+func parquetFloat(element *parquet.SchemaElement, value interface{}) (float64, error) {
+	var scale int32
+	decimal := true
+	switch {
+	case element.IsSetLogicalType() && element.LogicalType.IsSetDECIMAL():
+		scale = element.LogicalType.DECIMAL.Scale
+	case element.IsSetConvertedType() && element.GetConvertedType() == parquet.ConvertedType_DECIMAL:
+		scale = element.GetScale()
+	default:
+		decimal = false
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v) / math.Pow10(int(scale)), nil
+	case int32:
+		return float64(v) / math.Pow10(int(scale)), nil
+	case string:
+		// decimals stored as big endian two's complement byte arrays
+		if !decimal {
+			break
+		}
+
+		unscaled := new(big.Int).SetBytes([]byte(v))
+		if len(v) > 0 && v[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(8*len(v))))
+		}
+
+		f, _ := new(big.Float).Quo(new(big.Float).SetInt(unscaled), new(big.Float).SetFloat64(math.Pow10(int(scale)))).Float64()
+		return f, nil
+	}
+
+	return 0, errors.Errorf("unsupported value of type %T", value)
+}
+
+/*
+SaveBars - Writes bars to path in the format of its extension, .csv with the
+default CSVOptions, .jsonl or .parquet with the default columns. The file is
+replaced atomically.
+*/
+func SaveBars(path string, bars []Bar) error {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		return writeFileAtomic(path, func(w io.Writer) error {
+			return WriteBarsCSV(w, bars, CSVOptions{})
+		})
+	case ".jsonl":
+		return writeFileAtomic(path, func(w io.Writer) error {
+			return WriteBarsJSONL(w, bars)
+		})
+	case ".parquet":
+		return writeFileAtomic(path, func(w io.Writer) error {
+			return WriteBarsParquet(w, bars, nil)
+		})
+	default:
+		return errors.Errorf("unsupported bar file format '%s'", ext)
+	}
+}
+
+// LoadBars - reads bars saved by SaveBars
+func LoadBars(path string) ([]Bar, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".csv" && ext != ".jsonl" && ext != ".parquet" {
+		return nil, errors.Errorf("unsupported bar file format '%s'", ext)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch ext {
+	case ".csv":
+		return ReadBarsCSV(f, CSVOptions{})
+	case ".parquet":
+		info, err := f.Stat()
+		if err != nil {
+			return nil, err
+		}
+		return ReadBarsParquet(f, info.Size())
+	default:
+		return ReadBarsJSONL(f)
+	}
+}
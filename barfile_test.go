@@ -0,0 +1,162 @@
+package ibweb
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func testBars() []Bar {
+	start := time.Date(2023, 11, 27, 14, 30, 0, 0, time.UTC)
+	return []Bar{
+		{Time: start, Open: 189.5, High: 190.25, Low: 189.1, Close: 190, Volume: 12000},
+		{Time: start.Add(time.Minute), Open: 190, High: 190.4, Low: 189.9, Close: 190.31, Volume: 8500.5},
+	}
+}
+
+func TestBarsCSVUnit(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name string
+		opts CSVOptions
+		want string
+	}{
+		{
+			name: "default",
+			want: "time,open,high,low,close,volume\n" +
+				"2023-11-27T14:30:00Z,189.5,190.25,189.1,190,12000\n" +
+				"2023-11-27T14:31:00Z,190,190.4,189.9,190.31,8500.5\n",
+		},
+		{
+			name: "unix millis",
+			opts: CSVOptions{Columns: []BarColumn{ColumnTime, ColumnClose}, TimeFormat: TimeUnixMilli},
+			want: "time,close\n1701095400000,190\n1701095460000,190.31\n",
+		},
+		{
+			name: "layout in zone",
+			opts: CSVOptions{Columns: []BarColumn{ColumnTime, ColumnVolume}, TimeFormat: "2006-01-02 15:04:05", Location: loc},
+			want: "time,volume\n2023-11-27 09:30:00,12000\n2023-11-27 09:31:00,8500.5\n",
+		},
+	}
+
+	for _, tc := range tests {
+		var buf bytes.Buffer
+		assert.Nil(t, WriteBarsCSV(&buf, testBars(), tc.opts), tc.name)
+		assert.Equal(t, tc.want, buf.String(), tc.name)
+
+		bars, err := ReadBarsCSV(strings.NewReader(buf.String()), tc.opts)
+		assert.Nil(t, err, tc.name)
+		if assert.Len(t, bars, 2, tc.name) {
+			assert.True(t, bars[1].Time.Equal(testBars()[1].Time), tc.name)
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NotNil(t, WriteBarsCSV(&buf, testBars(), CSVOptions{Columns: []BarColumn{"trades"}}))
+
+	_, err = ReadBarsCSV(strings.NewReader("time,close\nyesterday,1\n"), CSVOptions{})
+	assert.NotNil(t, err)
+
+	_, err = ReadBarsCSV(strings.NewReader("time,close\n2023-11-27T14:30:00Z,abc\n"), CSVOptions{})
+	assert.NotNil(t, err)
+}
+
+func TestBarsJSONLUnit(t *testing.T) {
+	var buf bytes.Buffer
+	assert.Nil(t, WriteBarsJSONL(&buf, testBars()))
+	assert.True(t, strings.HasPrefix(buf.String(),
+		`{"time":"2023-11-27T14:30:00Z","open":189.5,"high":190.25,"low":189.1,"close":190,"volume":12000}`+"\n"))
+
+	bars, err := ReadBarsJSONL(strings.NewReader(buf.String() + "\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, testBars(), bars)
+
+	_, err = ReadBarsJSONL(strings.NewReader("{"))
+	assert.NotNil(t, err)
+}
+
+func TestBarsParquetUnit(t *testing.T) {
+	bars := testBars()
+	bars[0].VWAP = 189.8
+	bars[1].VWAP = 190.12
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteBarsParquet(&buf, bars, append(DefaultBarColumns, ColumnVWAP)))
+	assert.Equal(t, "PAR1", buf.String()[:4])
+	assert.Equal(t, "PAR1", buf.String()[buf.Len()-4:])
+
+	got, err := ReadBarsParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+	assert.Equal(t, bars, got)
+
+	buf.Reset()
+	assert.Nil(t, WriteBarsParquet(&buf, nil, nil))
+	got, err = ReadBarsParquet(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+
+	assert.NotNil(t, WriteBarsParquet(&buf, bars, []BarColumn{"trades"}))
+
+	_, err = ReadBarsParquet(strings.NewReader("time,close\n"), 11)
+	assert.NotNil(t, err)
+}
+
+// bars_ibweb.parquet was written by WriteBarsParquet and read back with DuckDB
+// v1.1.3, bars_duckdb.parquet was written by DuckDB with a TIMESTAMP_MICROS time,
+// DECIMAL prices, a null vwap and a symbol column
+func TestBarsParquetInteropUnit(t *testing.T) {
+	bars := testBars()
+	bars[0].VWAP = 189.8
+	bars[1].VWAP = 190.12
+
+	want, err := os.ReadFile("./testdata/bars_ibweb.parquet")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var buf bytes.Buffer
+	assert.Nil(t, WriteBarsParquet(&buf, bars, append(DefaultBarColumns, ColumnVWAP)))
+	assert.Equal(t, want, buf.Bytes())
+
+	file, err := os.ReadFile("./testdata/bars_duckdb.parquet")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	got, err := ReadBarsParquet(bytes.NewReader(file), int64(len(file)))
+	assert.Nil(t, err)
+
+	start := time.Date(2023, 11, 27, 14, 30, 0, 0, time.UTC)
+	assert.Equal(t, []Bar{
+		{Time: start, Open: 189.5, High: 190.25, Low: 189.1, Close: 190, Volume: 12000, VWAP: 189.8},
+		{Time: start.Add(time.Minute), Open: 190, High: 190.4, Low: 189.9, Close: 190.31, Volume: 8500},
+		{Time: start.Add(2 * time.Minute), Open: 190.31, High: 190.5, Low: 190.2, Close: 190.45, Volume: 300, VWAP: 190.4},
+	}, got)
+
+	_, err = ReadBarsParquet(bytes.NewReader(file[:len(file)-10]), int64(len(file)-10))
+	assert.NotNil(t, err)
+}
+
+func TestSaveBarsUnit(t *testing.T) {
+	dir := t.TempDir()
+
+	for _, name := range []string{"bars.csv", "bars.jsonl", "bars.parquet"} {
+		path := filepath.Join(dir, name)
+		assert.Nil(t, SaveBars(path, testBars()), name)
+
+		bars, err := LoadBars(path)
+		assert.Nil(t, err, name)
+		assert.Equal(t, testBars(), bars, name)
+	}
+
+	assert.NotNil(t, SaveBars(filepath.Join(dir, "bars.xlsx"), testBars()))
+	_, err := LoadBars(filepath.Join(dir, "bars.xlsx"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,226 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	bondFiltersPath = "v1/api/iserver/secdef/bond-filters"
+
+	// bondMaturityFilter - display text of the bond filter listing maturity months
+	bondMaturityFilter = "Maturity Date"
+)
+
+// BondIssuer - Issuer of bonds returned by SearchContracts for the Bonds SecType
+type BondIssuer struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+/*
+BondFiltersInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1secdef~1bond-filters/get
+*/
+type BondFiltersInput struct {
+	Symbol   string
+	IssuerID string
+}
+
+func (b BondFiltersInput) toQuery() []query {
+	symbol := b.Symbol
+	if symbol == "" {
+		symbol = "IssuerId"
+	}
+
+	return []query{
+		{
+			key:   "symbol",
+			value: symbol,
+		},
+		{
+			key:   "issuerId",
+			value: b.IssuerID,
+		},
+	}
+}
+
+/*
+BondFilters - Filters available to narrow down the bonds of an issuer
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1secdef~1bond-filters/get
+*/
+type BondFilters struct {
+	BondFilters []struct {
+		DisplayText string `json:"displayText"`
+		ColumnID    int    `json:"columnId"`
+		Options     []struct {
+			Text  string `json:"text"`
+			Value string `json:"value"`
+		} `json:"options"`
+	} `json:"bondFilters"`
+}
+
+// Values - gets the option values of the filter with the display text
+func (b BondFilters) Values(displayText string) []string {
+	var values []string
+	for _, filter := range b.BondFilters {
+		if !strings.EqualFold(filter.DisplayText, displayText) {
+			continue
+		}
+
+		for _, option := range filter.Options {
+			values = append(values, option.Value)
+		}
+	}
+
+	return values
+}
+
+// Bond - Typed bond fields of a security definition
+type Bond struct {
+	Conid       int
+	IssuerID    string
+	Symbol      string
+	Cusip       string
+	Coupon      float64
+	Maturity    time.Time
+	Currency    string
+	Description string
+}
+
+// CouponRate - gets the parsed coupon, zero for bonds without a coupon
+func (s SecurityDefinitionInfo) CouponRate() (float64, error) {
+	coupon := strings.TrimSpace(strings.TrimSuffix(s.Coupon, "%"))
+	if coupon == "" || strings.EqualFold(coupon, "No Coupon") {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseFloat(coupon, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid coupon '%s'", s.Coupon)
+	}
+
+	return v, nil
+}
+
+// NewBond - returns the typed bond fields of a bond security definition
+func NewBond(info SecurityDefinitionInfo, issuerID string) (Bond, error) {
+	coupon, err := info.CouponRate()
+	if err != nil {
+		return Bond{}, err
+	}
+
+	maturity, err := info.Maturity()
+	if err != nil {
+		return Bond{}, err
+	}
+
+	return Bond{
+		Conid:       info.Conid,
+		IssuerID:    issuerID,
+		Symbol:      info.Symbol,
+		Cusip:       info.Cusip,
+		Coupon:      coupon,
+		Maturity:    maturity,
+		Currency:    info.Currency,
+		Description: strings.TrimSpace(info.Desc1 + " " + info.Desc2),
+	}, nil
+}
+
+// SearchBondIssuers - searches for the issuers of bonds matching symbol, e.g. US-T
+func SearchBondIssuers(c Client, symbol string) ([]BondIssuer, error) {
+	contracts, err := c.SearchContracts(SearchContractsInput{
+		Symbol:  symbol,
+		SecType: Bonds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var issuers []BondIssuer
+	seen := map[string]bool{}
+	for _, contract := range contracts {
+		for _, issuer := range contract.Issuers {
+			if seen[issuer.ID] {
+				continue
+			}
+			seen[issuer.ID] = true
+			issuers = append(issuers, issuer)
+		}
+	}
+
+	return issuers, nil
+}
+
+/*
+BondsByIssuer - Enumerates every bond of an issuer by getting the security
+definitions of each maturity month listed in the issuer's bond filters
+*/
+func BondsByIssuer(c Client, issuerID string) ([]Bond, error) {
+	filters, err := c.BondFilters(BondFiltersInput{IssuerID: issuerID})
+	if err != nil {
+		return nil, err
+	}
+
+	var bonds []Bond
+	seen := map[int]bool{}
+	for _, maturity := range filters.Values(bondMaturityFilter) {
+		infos, err := c.SecurityDefinitionInfo(SecurityDefinitionInfoInput{
+			SecType:  Bonds,
+			IssuerID: issuerID,
+			Filters:  "maturityDate:" + maturity,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get bonds of issuer '%s' maturing %s", issuerID, maturity)
+		}
+
+		for _, info := range infos {
+			if seen[info.Conid] {
+				continue
+			}
+			seen[info.Conid] = true
+
+			bond, err := NewBond(info, issuerID)
+			if err != nil {
+				return nil, err
+			}
+
+			bonds = append(bonds, bond)
+		}
+	}
+
+	return bonds, nil
+}
+
+/*
+BondFilters - Gets the filters available for the bonds of an issuer
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1secdef~1bond-filters/get
+*/
+func (c *client) BondFilters(input BondFiltersInput) (*BondFilters, error) {
+	resp, err := c.get(bondFiltersPath, input.toQuery()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var bondFilters BondFilters
+	if err := json.Unmarshal(v, &bondFilters); err != nil {
+		return nil, err
+	}
+
+	return &bondFilters, nil
+}
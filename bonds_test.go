@@ -0,0 +1,203 @@
+package ibweb
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBondFiltersIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	issuers, err := SearchBondIssuers(c, "US-T")
+	assert.Nil(t, err)
+	assert.Greater(t, len(issuers), 0)
+
+	if !t.Failed() {
+		filters, err := c.BondFilters(BondFiltersInput{IssuerID: issuers[0].ID})
+		assert.Nil(t, err)
+		assert.Greater(t, len(filters.BondFilters), 0)
+	}
+}
+
+func TestBondFiltersUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get bond filters")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get bond filters",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read bond filters")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read bond filters",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/bond_filters.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", bondFiltersPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.BondFilters(BondFiltersInput{IssuerID: "e1359061"})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestBondsByIssuerUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", searchContractsPath),
+		httpmock.NewStringResponder(200, httpmock.File("./testdata/bond_issuers.json").String()))
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", bondFiltersPath),
+		httpmock.NewStringResponder(200, httpmock.File("./testdata/bond_filters.json").String()))
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", secDefInfoPath),
+		func(req *http.Request) (*http.Response, error) {
+			q := req.URL.Query()
+			if q.Get("sectype") != "BOND" || q.Get("issuerId") != "e1359061" || q.Has("conid") {
+				return httpmock.NewStringResponse(400, `{"error":"bad request"}`), nil
+			}
+
+			switch q.Get("filters") {
+			case "maturityDate:202502":
+				return httpmock.NewStringResponse(200, `[{"conid":1,"symbol":"US-T","secType":"BOND","currency":"USD","cusip":"91282CDZ1","coupon":"1.125","desc1":"US-T","desc2":"1 1/8 02/15/25","maturityDate":"20250215"}]`), nil
+			default:
+				return httpmock.NewStringResponse(200, `[{"conid":2,"symbol":"US-T","secType":"BOND","currency":"USD","cusip":"91282CGN5","coupon":"4.25","desc1":"US-T","desc2":"4 1/4 03/31/25","maturityDate":"20250331"},{"conid":1,"symbol":"US-T","secType":"BOND","currency":"USD","cusip":"91282CDZ1","coupon":"1.125","maturityDate":"20250215"}]`), nil
+			}
+		})
+
+	c := New("http://127.0.0.1:5555")
+
+	issuers, err := SearchBondIssuers(c, "US-T")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []BondIssuer{{ID: "e1359061", Name: "United States Treasury"}}, issuers)
+
+	bonds, err := BondsByIssuer(c, issuers[0].ID)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, []Bond{
+		{
+			Conid:       1,
+			IssuerID:    "e1359061",
+			Symbol:      "US-T",
+			Cusip:       "91282CDZ1",
+			Coupon:      1.125,
+			Maturity:    time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC),
+			Currency:    "USD",
+			Description: "US-T 1 1/8 02/15/25",
+		},
+		{
+			Conid:       2,
+			IssuerID:    "e1359061",
+			Symbol:      "US-T",
+			Cusip:       "91282CGN5",
+			Coupon:      4.25,
+			Maturity:    time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+			Currency:    "USD",
+			Description: "US-T 4 1/4 03/31/25",
+		},
+	}, bonds)
+
+	_, err = BondsByIssuer(c, "unknown")
+	assertError(t, true, "invalid status code", err)
+
+	coupon, err := SecurityDefinitionInfo{Coupon: "No Coupon"}.CouponRate()
+	assert.Nil(t, err)
+	assert.Equal(t, 0.0, coupon)
+
+	_, err = NewBond(SecurityDefinitionInfo{Coupon: "abc"}, "")
+	assertError(t, true, "invalid coupon", err)
+}
@@ -49,6 +49,11 @@ type Position struct {
 	Model             string   `json:"model"`
 }
 
+// ContractID - gets the typed contract ID
+func (p Position) ContractID() Conid {
+	return Conid(p.Conid)
+}
+
 /*
 PositionsByContractID - Gets positions by contract ID
 Link: https://www.interactivebrokers.com/api/doc.html#tag/Portfolio/paths/~1portfolio~1%7BaccountId%7D~1ledger/get
@@ -7,21 +7,32 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 )
 
 var (
 	newRequestFn = http.NewRequest
 	readAllFn    = io.ReadAll
+	nowFn        = time.Now
+	sleepFn      = time.Sleep
 )
 
 // Client - Client Portal Web API Interface
 type Client interface {
 	SetClient(httpClient *http.Client)
+	SetDelayedDataPolicy(policy DelayedDataPolicy)
 
 	// Contracts
 	SearchContracts(input SearchContractsInput) ([]Contract, error)
 	SearchStrikes(input SearchStrikesInput) (*SearchStrikes, error)
 	SecurityDefinitionInfo(input SecurityDefinitionInfoInput) ([]SecurityDefinitionInfo, error)
+	TradingSchedule(input TradingScheduleInput) ([]TradingSchedule, error)
+	SecDefByConids(conids []int) (*SecDefByConids, error)
+	AllConids(input AllConidsInput) ([]ExchangeConid, error)
+	Algos(input AlgosInput) (*Algos, error)
+	BondFilters(input BondFiltersInput) (*BondFilters, error)
+	CurrencyPairs(currency string) (CurrencyPairs, error)
+	ExchangeRate(input ExchangeRateInput) (*ExchangeRate, error)
 
 	// Portfolio
 	PortfolioAccounts() ([]PortfolioAccount, error)
@@ -32,13 +43,25 @@ type Client interface {
 
 	// Order
 	PlaceOrders(accountID string, input PlaceOrdersInput) ([]PlaceOrders, error)
+	PreviewOrders(accountID string, input PlaceOrdersInput) (*OrderPreview, error)
 	PlaceOrderReply(replyID string, input PlaceOrderReplyInput) ([]PlaceOrders, error)
+	ModifyOrder(accountID, orderID string, order Order) ([]PlaceOrders, error)
 	CancelOrder(accountID, orderID string) (*CancelOrder, error)
 	LiveOrders() (*LiveOrders, error)
 	OrderStatus(orderID string) (*OrderStatus, error)
 
 	// Market Data
 	MarketDataHistory(input MarketDataHistoryInput) (*MarketDataHistory, error)
+	HMDSHistory(input HMDSHistoryInput) (*MarketDataHistory, error)
+	MarketDataSnapshot(input MarketDataSnapshotInput) ([]Snapshot, error)
+	Snapshot(conids []int, fields []SnapshotField) ([]Snapshot, error)
+	UnsubscribeMarketData(conid int) (*UnsubscribeMarketData, error)
+	UnsubscribeAllMarketData() (*UnsubscribeAllMarketData, error)
+
+	// Scanner
+	ScannerParams() (*ScannerParams, error)
+	RunScanner(input ScannerRunInput) (*ScannerResult, error)
+	HMDSScanner(input HMDSScannerInput) (*HMDSScannerResult, error)
 
 	//CCP
 	PositionByContractID(accountID, conID string) ([]Position, error)
@@ -48,6 +71,8 @@ type client struct {
 	httpClient *http.Client
 	url        string
 	doFn       func(req *http.Request) (*http.Response, error)
+
+	delayedDataPolicy DelayedDataPolicy
 }
 
 // New - returns a new Client with the URL past
@@ -74,6 +99,11 @@ func (c *client) SetClient(httpClient *http.Client) {
 	c.httpClient = httpClient
 }
 
+// SetDelayedDataPolicy - sets the policy applied to delayed market data, allowed by default
+func (c *client) SetDelayedDataPolicy(policy DelayedDataPolicy) {
+	c.delayedDataPolicy = policy
+}
+
 func substituteParam(path string, params ...param) string {
 	for _, p := range params {
 		key := "{" + p.key + "}"
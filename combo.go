@@ -0,0 +1,257 @@
+package ibweb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	conidexSpreadSeparator = ";;;"
+	conidexLegSeparator    = ","
+	conidexRatioSeparator  = "/"
+)
+
+// spreadConids - conids of the combo spread contract by currency
+var spreadConids = map[string]int{
+	"AUD": 61227077,
+	"CAD": 61227082,
+	"CHF": 61227087,
+	"CNH": 136000441,
+	"GBP": 58666491,
+	"HKD": 61227072,
+	"INR": 136000444,
+	"JPY": 61227069,
+	"KRW": 136000424,
+	"MXN": 136000449,
+	"SEK": 136000429,
+	"SGD": 426116555,
+	"USD": 28812380,
+}
+
+// SpreadConid - gets the spread conid used for combos in a currency
+func SpreadConid(currency string) (int, bool) {
+	conid, ok := spreadConids[strings.ToUpper(currency)]
+	return conid, ok
+}
+
+func spreadCurrency(conid int) (string, bool) {
+	for currency, spreadConid := range spreadConids {
+		if spreadConid == conid {
+			return currency, true
+		}
+	}
+
+	return "", false
+}
+
+// ComboLeg - A leg of a combo, Ratio is always positive and Side gives the direction
+type ComboLeg struct {
+	Conid    int
+	Ratio    int
+	Side     OrderSide
+	Currency string
+}
+
+/*
+Combo - Builds the conidex of a combo (BAG) order, formatted as
+{spread_conid};;;{leg_conid}/{ratio},... with sell legs having negative ratios
+*/
+type Combo struct {
+	Currency string
+	Legs     []ComboLeg
+}
+
+// NewCombo - returns an empty Combo
+func NewCombo() *Combo {
+	return &Combo{}
+}
+
+// AddLeg - adds a leg by conid, the currency is filled in by Resolve
+func (c *Combo) AddLeg(conid, ratio int, side OrderSide) *Combo {
+	c.Legs = append(c.Legs, ComboLeg{
+		Conid: conid,
+		Ratio: ratio,
+		Side:  side,
+	})
+
+	return c
+}
+
+// AddSecurityDefinition - adds a leg from a resolved security definition
+func (c *Combo) AddSecurityDefinition(info SecurityDefinitionInfo, ratio int, side OrderSide) *Combo {
+	c.Legs = append(c.Legs, ComboLeg{
+		Conid:    info.Conid,
+		Ratio:    ratio,
+		Side:     side,
+		Currency: info.Currency,
+	})
+
+	return c
+}
+
+// Resolve - fills in the currency of legs added without one
+func (c *Combo) Resolve(client Client) error {
+	var conids []int
+	for _, leg := range c.Legs {
+		if leg.Currency == "" {
+			conids = append(conids, leg.Conid)
+		}
+	}
+
+	if len(conids) == 0 {
+		return nil
+	}
+
+	secDefs, err := client.SecDefByConids(conids)
+	if err != nil {
+		return err
+	}
+
+	if len(secDefs.Missing) > 0 {
+		return errors.Errorf("no security definition found for combo legs %v", secDefs.Missing)
+	}
+
+	for i, leg := range c.Legs {
+		if leg.Currency == "" {
+			c.Legs[i].Currency = secDefs.SecDefs[leg.Conid].Currency
+		}
+	}
+
+	return nil
+}
+
+/*
+Validate - Checks the combo has at least two distinct legs with positive ratios
+and a side, all in a single currency that has a spread conid
+*/
+func (c *Combo) Validate() error {
+	if len(c.Legs) < 2 {
+		return errors.New("combo requires at least two legs")
+	}
+
+	currency := strings.ToUpper(c.Currency)
+	seen := map[int]bool{}
+	for _, leg := range c.Legs {
+		if seen[leg.Conid] {
+			return errors.Errorf("combo leg conid '%d' is repeated", leg.Conid)
+		}
+		seen[leg.Conid] = true
+
+		if leg.Ratio <= 0 {
+			return errors.Errorf("combo leg conid '%d' has invalid ratio %d", leg.Conid, leg.Ratio)
+		}
+
+		if leg.Side != Buy && leg.Side != Sell {
+			return errors.Errorf("combo leg conid '%d' has invalid side '%s'", leg.Conid, leg.Side)
+		}
+
+		if leg.Currency == "" {
+			return errors.Errorf("combo leg conid '%d' has no currency, resolve the combo first", leg.Conid)
+		}
+
+		if currency == "" {
+			currency = strings.ToUpper(leg.Currency)
+		}
+
+		if !strings.EqualFold(leg.Currency, currency) {
+			return errors.Errorf("combo leg conid '%d' currency '%s' does not match '%s'", leg.Conid, leg.Currency, currency)
+		}
+	}
+
+	if _, ok := SpreadConid(currency); !ok {
+		return errors.Errorf("no spread conid for currency '%s'", currency)
+	}
+
+	c.Currency = currency
+	return nil
+}
+
+// Conidex - validates the combo and formats its conidex
+func (c *Combo) Conidex() (string, error) {
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+
+	spreadConid, _ := SpreadConid(c.Currency)
+
+	legs := make([]string, 0, len(c.Legs))
+	for _, leg := range c.Legs {
+		ratio := leg.Ratio
+		if leg.Side == Sell {
+			ratio = -ratio
+		}
+
+		legs = append(legs, fmt.Sprintf("%d%s%d", leg.Conid, conidexRatioSeparator, ratio))
+	}
+
+	return fmt.Sprintf("%d%s%s", spreadConid, conidexSpreadSeparator, strings.Join(legs, conidexLegSeparator)), nil
+}
+
+// Apply - returns a copy of the order placed on the combo
+func (c *Combo) Apply(order Order) (Order, error) {
+	conidex, err := c.Conidex()
+	if err != nil {
+		return Order{}, err
+	}
+
+	order.Conid = 0
+	order.Conidex = conidex
+	return order, nil
+}
+
+/*
+ParseConidex - Parses a combo conidex, such as the Conidex of a LiveOrders entry.
+Leg currencies are taken from the spread conid.
+*/
+func ParseConidex(conidex string) (*Combo, error) {
+	parts := strings.SplitN(conidex, conidexSpreadSeparator, 2)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("conidex '%s' is not a combo", conidex)
+	}
+
+	spreadConid, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid combo spread conid '%s'", parts[0])
+	}
+
+	currency, ok := spreadCurrency(spreadConid)
+	if !ok {
+		return nil, errors.Errorf("unknown combo spread conid '%d'", spreadConid)
+	}
+
+	combo := &Combo{Currency: currency}
+	for _, l := range strings.Split(parts[1], conidexLegSeparator) {
+		legParts := strings.Split(l, conidexRatioSeparator)
+		if len(legParts) != 2 {
+			return nil, errors.Errorf("invalid combo leg '%s'", l)
+		}
+
+		conid, err := strconv.Atoi(legParts[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid combo leg conid '%s'", legParts[0])
+		}
+
+		ratio, err := strconv.Atoi(legParts[1])
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid combo leg ratio '%s'", legParts[1])
+		}
+
+		side := Buy
+		if ratio < 0 {
+			side = Sell
+			ratio = -ratio
+		}
+
+		combo.Legs = append(combo.Legs, ComboLeg{
+			Conid:    conid,
+			Ratio:    ratio,
+			Side:     side,
+			Currency: currency,
+		})
+	}
+
+	return combo, nil
+}
@@ -0,0 +1,134 @@
+package ibweb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComboUnit(t *testing.T) {
+	tests := []struct {
+		name            string
+		combo           *Combo
+		want            string
+		wantErrContains string
+	}{
+		{
+			"builds vertical spread",
+			NewCombo().
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 659248794, Currency: "USD"}, 1, Buy).
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 659250825, Currency: "USD"}, 1, Sell),
+			"28812380;;;659248794/1,659250825/-1",
+			"",
+		},
+		{
+			"handles single leg",
+			NewCombo().AddSecurityDefinition(SecurityDefinitionInfo{Conid: 1, Currency: "USD"}, 1, Buy),
+			"",
+			"at least two legs",
+		},
+		{
+			"handles repeated leg",
+			NewCombo().
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 1, Currency: "USD"}, 1, Buy).
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 1, Currency: "USD"}, 1, Sell),
+			"",
+			"is repeated",
+		},
+		{
+			"handles invalid ratio",
+			NewCombo().
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 1, Currency: "USD"}, 0, Buy).
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 2, Currency: "USD"}, 1, Sell),
+			"",
+			"invalid ratio",
+		},
+		{
+			"handles unresolved leg",
+			NewCombo().AddLeg(1, 1, Buy).AddLeg(2, 1, Sell),
+			"",
+			"resolve the combo first",
+		},
+		{
+			"handles mixed currencies",
+			NewCombo().
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 1, Currency: "USD"}, 1, Buy).
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 2, Currency: "GBP"}, 1, Sell),
+			"",
+			"does not match",
+		},
+		{
+			"handles currency without spread conid",
+			NewCombo().
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 1, Currency: "XXX"}, 1, Buy).
+				AddSecurityDefinition(SecurityDefinitionInfo{Conid: 2, Currency: "XXX"}, 1, Sell),
+			"",
+			"no spread conid",
+		},
+	}
+
+	for _, tc := range tests {
+		got, err := tc.combo.Conidex()
+		if !assertError(t, tc.wantErrContains != "", tc.wantErrContains, err) {
+			t.Log(tc.name)
+			continue
+		}
+
+		assert.Equal(t, tc.want, got, tc.name)
+	}
+}
+
+func TestComboResolveAndApplyUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", secDefPath),
+		httpmock.NewStringResponder(200, httpmock.File("./testdata/secdef.json").String()))
+
+	c := New("http://127.0.0.1:5555")
+
+	combo := NewCombo().AddLeg(265598, 100, Buy).AddLeg(659248794, 1, Sell)
+	if !assert.Nil(t, combo.Resolve(c)) {
+		t.FailNow()
+	}
+
+	order, err := combo.Apply(Order{Conid: 265598, OrderType: Limit, Side: Buy, Quantity: 1})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 0, order.Conid)
+	assert.Equal(t, "28812380;;;265598/100,659248794/-1", order.Conidex)
+
+	err = NewCombo().AddLeg(265598, 1, Buy).AddLeg(1, 1, Sell).Resolve(c)
+	assertError(t, true, "no security definition found for combo legs [1]", err)
+}
+
+func TestParseConidexUnit(t *testing.T) {
+	combo, err := ParseConidex("28812380;;;659248794/1,659250825/-2")
+	if assert.Nil(t, err) {
+		assert.Equal(t, &Combo{
+			Currency: "USD",
+			Legs: []ComboLeg{
+				{Conid: 659248794, Ratio: 1, Side: Buy, Currency: "USD"},
+				{Conid: 659250825, Ratio: 2, Side: Sell, Currency: "USD"},
+			},
+		}, combo)
+	}
+
+	_, err = ParseConidex("265598@SMART")
+	assertError(t, true, "is not a combo", err)
+
+	_, err = ParseConidex("1;;;659248794/1")
+	assertError(t, true, "unknown combo spread conid", err)
+
+	_, err = ParseConidex("28812380;;;659248794")
+	assertError(t, true, "invalid combo leg", err)
+
+	_, err = ParseConidex("28812380;;;659248794/x")
+	assertError(t, true, "invalid combo leg ratio", err)
+}
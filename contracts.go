@@ -3,13 +3,24 @@ package ibweb
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
 )
 
 const (
 	searchContractsPath = "v1/api/iserver/secdef/search"
 	searchStrikesPath   = "v1/api/iserver/secdef/strikes"
 	secDefInfoPath      = "v1/api/iserver/secdef/info"
+	secDefPath          = "v1/api/trsrv/secdef"
+	allConidsPath       = "v1/api/trsrv/all-conids"
+
+	// secDefChunkSize - max number of conids sent to the gateway in a single secdef request
+	secDefChunkSize = 100
 )
 
 // SecType - Security type of contract
@@ -19,6 +30,7 @@ const (
 	Options SecType = "OPT"
 	Stock   SecType = "STK"
 	War     SecType = "WAR"
+	Bonds   SecType = "BOND"
 )
 
 // Right - Options right
@@ -44,22 +56,92 @@ Contract -
 Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1secdef~1search/post
 */
 type Contract struct {
-	Conid         string `json:"conid"`
-	CompanyHeader string `json:"companyHeader"`
-	CompanyName   string `json:"companyName"`
-	Symbol        string `json:"symbol"`
-	Description   string `json:"description"`
-	Restricted    string `json:"restricted"`
-	Fop           string `json:"fop"`
-	Opt           string `json:"opt"`
-	War           string `json:"war"`
-	Sections      []struct {
-		SecType    SecType `json:"secType"`
-		Months     string  `json:"months"`
-		Symbol     string  `json:"symbol"`
-		Exchange   string  `json:"exchange"`
-		LegSecType string  `json:"legSecType"`
-	} `json:"sections"`
+	Conid         string            `json:"conid"`
+	CompanyHeader string            `json:"companyHeader"`
+	CompanyName   string            `json:"companyName"`
+	Symbol        string            `json:"symbol"`
+	Description   string            `json:"description"`
+	Restricted    string            `json:"restricted"`
+	Fop           string            `json:"fop"`
+	Opt           string            `json:"opt"`
+	War           string            `json:"war"`
+	Sections      []ContractSection `json:"sections"`
+	BondID        int               `json:"bondid"`
+	Issuers       []BondIssuer      `json:"issuers"`
+}
+
+// ContractSection - Security type available for a contract
+type ContractSection struct {
+	SecType    SecType `json:"secType"`
+	Months     string  `json:"months"`
+	Symbol     string  `json:"symbol"`
+	Exchange   string  `json:"exchange"`
+	LegSecType string  `json:"legSecType"`
+}
+
+// Conid - Interactive brokers contract ID
+type Conid int
+
+// ParseConid - parses a contract ID formatted as a string
+func ParseConid(s string) (Conid, error) {
+	v, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid conid '%s'", s)
+	}
+
+	return Conid(v), nil
+}
+
+func (c Conid) String() string {
+	return strconv.Itoa(int(c))
+}
+
+// UnmarshalJSON - accepts the contract ID as either a number or a string
+func (c *Conid) UnmarshalJSON(v []byte) error {
+	var i int
+	if err := json.Unmarshal(v, &i); err == nil {
+		*c = Conid(i)
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(v, &s); err != nil {
+		return errors.Wrap(err, "failed to find appropriate type to unmarshal conid into")
+	}
+
+	parsed, err := ParseConid(s)
+	if err != nil {
+		return err
+	}
+
+	*c = parsed
+	return nil
+}
+
+// ContractID - gets the typed contract ID
+func (c Contract) ContractID() (Conid, error) {
+	return ParseConid(c.Conid)
+}
+
+// MonthList - gets the months of the section, e.g. DEC23
+func (c ContractSection) MonthList() []string {
+	return splitList(c.Months, ";")
+}
+
+// Exchanges - gets the exchanges of the section
+func (c ContractSection) Exchanges() []string {
+	return splitList(c.Exchange, ";")
+}
+
+func splitList(s, sep string) []string {
+	var list []string
+	for _, v := range strings.Split(s, sep) {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
 }
 
 /*
@@ -119,20 +201,27 @@ type SecurityDefinitionInfoInput struct {
 	Exchange string
 	Strike   float64
 	Right    string
+	IssuerID string
+	// Filters - bond filters as field:value, e.g. maturityDate:202502
+	Filters string
 }
 
 func (s SecurityDefinitionInfoInput) toQuery() []query {
 	queries := []query{
-		{
-			key:   "conid",
-			value: s.ConID,
-		},
 		{
 			key:   "sectype",
 			value: string(s.SecType),
 		},
 	}
 
+	// bonds are looked up by issuer without a conid
+	if s.ConID != "" {
+		queries = append(queries, query{
+			key:   "conid",
+			value: s.ConID,
+		})
+	}
+
 	if s.Month != "" {
 		queries = append(queries, query{
 			key:   "month",
@@ -161,6 +250,20 @@ func (s SecurityDefinitionInfoInput) toQuery() []query {
 		})
 	}
 
+	if s.IssuerID != "" {
+		queries = append(queries, query{
+			key:   "issuerId",
+			value: s.IssuerID,
+		})
+	}
+
+	if s.Filters != "" {
+		queries = append(queries, query{
+			key:   "filters",
+			value: s.Filters,
+		})
+	}
+
 	return queries
 }
 
@@ -187,6 +290,182 @@ type SecurityDefinitionInfo struct {
 	ValidExchanges  string  `json:"validExchanges"`
 }
 
+// ContractID - gets the typed contract ID
+func (s SecurityDefinitionInfo) ContractID() Conid {
+	return Conid(s.Conid)
+}
+
+// MultiplierValue - gets the parsed multiplier, zero when not set
+func (s SecurityDefinitionInfo) MultiplierValue() (float64, error) {
+	if s.Multiplier == "" {
+		return 0, nil
+	}
+
+	v, err := strconv.ParseFloat(s.Multiplier, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid multiplier '%s'", s.Multiplier)
+	}
+
+	return v, nil
+}
+
+// Maturity - gets the parsed maturity date, the zero time when not set
+func (s SecurityDefinitionInfo) Maturity() (time.Time, error) {
+	if s.MaturityDate == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(maturityLayout, s.MaturityDate)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid maturity date '%s'", s.MaturityDate)
+	}
+
+	return t, nil
+}
+
+// Exchanges - gets the valid exchanges
+func (s SecurityDefinitionInfo) Exchanges() []string {
+	return splitList(s.ValidExchanges, ",")
+}
+
+/*
+SecDefInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1secdef/post
+*/
+type SecDefInput struct {
+	Conids []int `json:"conids"`
+}
+
+/*
+SecDefResponse -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1secdef/post
+*/
+type SecDefResponse struct {
+	SecDef []SecDef `json:"secdef"`
+}
+
+/*
+SecDef - Security definition of a contract
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1secdef/post
+*/
+type SecDef struct {
+	Conid           int             `json:"conid"`
+	Currency        string          `json:"currency"`
+	Time            int             `json:"time"`
+	ChineseName     string          `json:"chineseName"`
+	AllExchanges    string          `json:"allExchanges"`
+	ListingExchange string          `json:"listingExchange"`
+	Name            string          `json:"name"`
+	AssetClass      string          `json:"assetClass"`
+	Expiry          string          `json:"expiry"`
+	LastTradingDay  string          `json:"lastTradingDay"`
+	Group           string          `json:"group"`
+	PutOrCall       string          `json:"putOrCall"`
+	Sector          string          `json:"sector"`
+	SectorGroup     string          `json:"sectorGroup"`
+	Strike          string          `json:"strike"`
+	Ticker          string          `json:"ticker"`
+	UndConid        int             `json:"undConid"`
+	Multiplier      float64         `json:"multiplier"`
+	Type            string          `json:"type"`
+	UndComp         string          `json:"undComp"`
+	UndSym          string          `json:"undSym"`
+	HasOptions      bool            `json:"hasOptions"`
+	FullName        string          `json:"fullName"`
+	IsUS            bool            `json:"isUS"`
+	IncrementRules  []IncrementRule `json:"incrementRules"`
+}
+
+// IncrementRule - Tick size of prices from LowerEdge up to the next rule
+type IncrementRule struct {
+	LowerEdge float64 `json:"lowerEdge"`
+	Increment float64 `json:"increment"`
+}
+
+// ContractID - gets the typed contract ID
+func (s SecDef) ContractID() Conid {
+	return Conid(s.Conid)
+}
+
+// TickSize - gets the increment of the rule price falls in, zero without rules
+func (s SecDef) TickSize(price float64) float64 {
+	price = math.Abs(price)
+
+	var tick float64
+	edge := math.Inf(-1)
+	for _, rule := range s.IncrementRules {
+		if rule.LowerEdge <= price && rule.LowerEdge >= edge {
+			tick, edge = rule.Increment, rule.LowerEdge
+		}
+	}
+
+	return tick
+}
+
+// RoundPrice - rounds price to the nearest tick, unchanged without rules
+func (s SecDef) RoundPrice(price float64) float64 {
+	return roundToTick(price, s.TickSize(price))
+}
+
+// roundToTick - rounds price to the nearest multiple of tick, unchanged without a tick
+func roundToTick(price, tick float64) float64 {
+	if tick <= 0 {
+		return price
+	}
+
+	// format with the decimals of the tick so 187.25000000000003 becomes 187.25
+	decimals := 0
+	if t := strconv.FormatFloat(tick, 'f', -1, 64); strings.Contains(t, ".") {
+		decimals = len(t) - strings.Index(t, ".") - 1
+	}
+
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(math.Round(price/tick)*tick, 'f', decimals, 64), 64)
+	return rounded
+}
+
+// SecDefByConids - Security definitions keyed by conid along with the conids the gateway did not return
+type SecDefByConids struct {
+	SecDefs map[int]SecDef
+	Missing []int
+}
+
+/*
+AllConidsInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1all-conids/get
+*/
+type AllConidsInput struct {
+	Exchange   string
+	AssetClass SecType
+}
+
+func (a AllConidsInput) toQuery() []query {
+	queries := []query{
+		{
+			key:   "exchange",
+			value: a.Exchange,
+		},
+	}
+
+	if a.AssetClass != "" {
+		queries = append(queries, query{
+			key:   "assetClass",
+			value: string(a.AssetClass),
+		})
+	}
+
+	return queries
+}
+
+/*
+ExchangeConid - Contract listed on an exchange
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1all-conids/get
+*/
+type ExchangeConid struct {
+	Ticker   string `json:"ticker"`
+	Conid    int    `json:"conid"`
+	Exchange string `json:"exchange"`
+}
+
 /*
 SearchContracts - Searches for a contract
 Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1secdef~1search/post
@@ -270,3 +549,100 @@ func (c *client) SecurityDefinitionInfo(input SecurityDefinitionInfoInput) ([]Se
 
 	return secDefInfo, nil
 }
+
+/*
+SecDefByConids - Gets the security definitions of conids, requesting them from the
+gateway in chunks of secDefChunkSize
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1secdef/post
+*/
+func (c *client) SecDefByConids(conids []int) (*SecDefByConids, error) {
+	secDefs := &SecDefByConids{
+		SecDefs: map[int]SecDef{},
+	}
+
+	seen := map[int]bool{}
+	unique := make([]int, 0, len(conids))
+	for _, conid := range conids {
+		if seen[conid] {
+			continue
+		}
+		seen[conid] = true
+		unique = append(unique, conid)
+	}
+
+	for start := 0; start < len(unique); start += secDefChunkSize {
+		end := start + secDefChunkSize
+		if end > len(unique) {
+			end = len(unique)
+		}
+
+		resp, err := c.secDef(SecDefInput{Conids: unique[start:end]})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, secDef := range resp.SecDef {
+			secDefs.SecDefs[secDef.Conid] = secDef
+		}
+	}
+
+	for _, conid := range unique {
+		if _, ok := secDefs.SecDefs[conid]; !ok {
+			secDefs.Missing = append(secDefs.Missing, conid)
+		}
+	}
+
+	return secDefs, nil
+}
+
+func (c *client) secDef(input SecDefInput) (*SecDefResponse, error) {
+	resp, err := c.post(secDefPath, &input)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var secDef SecDefResponse
+	if err := json.Unmarshal(v, &secDef); err != nil {
+		return nil, err
+	}
+
+	return &secDef, nil
+}
+
+/*
+AllConids - Gets every contract listed on an exchange
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1all-conids/get
+*/
+func (c *client) AllConids(input AllConidsInput) ([]ExchangeConid, error) {
+	resp, err := c.get(allConidsPath, input.toQuery()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var exchangeConids []ExchangeConid
+	if err := json.Unmarshal(v, &exchangeConids); err != nil {
+		return nil, err
+	}
+
+	return exchangeConids, nil
+}
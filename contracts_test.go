@@ -2,12 +2,14 @@ package ibweb
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -389,3 +391,373 @@ func TestSecurityDefinitionInfoUnit(t *testing.T) {
 		httpmock.DeactivateAndReset()
 	}
 }
+
+func TestSecDefByConidsIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	secDefs, err := c.SecDefByConids([]int{265598, 659248794})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Greater(t, len(secDefs.SecDefs), 0)
+}
+
+func TestSecDefByConidsUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to post",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to post security definitions")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to post security definitions",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read security definitions")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read security definitions",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/secdef.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", secDefPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.SecDefByConids([]int{265598})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestSecDefByConidsChunksUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	var requested [][]int
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", secDefPath),
+		func(req *http.Request) (*http.Response, error) {
+			var input SecDefInput
+			if err := json.NewDecoder(req.Body).Decode(&input); err != nil {
+				return nil, err
+			}
+			requested = append(requested, input.Conids)
+
+			// every odd conid is unknown to the gateway
+			var resp SecDefResponse
+			for _, conid := range input.Conids {
+				if conid%2 == 0 {
+					resp.SecDef = append(resp.SecDef, SecDef{Conid: conid})
+				}
+			}
+
+			return httpmock.NewJsonResponse(200, resp)
+		})
+
+	conids := make([]int, 0, secDefChunkSize*2+10)
+	for i := 0; i < secDefChunkSize*2+10; i++ {
+		conids = append(conids, i)
+	}
+	// duplicates are only requested once
+	conids = append(conids, 0, 1)
+
+	c := New("http://127.0.0.1:5555")
+	secDefs, err := c.SecDefByConids(conids)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	if assert.Len(t, requested, 3) {
+		assert.Len(t, requested[0], secDefChunkSize)
+		assert.Len(t, requested[1], secDefChunkSize)
+		assert.Len(t, requested[2], 10)
+	}
+
+	assert.Len(t, secDefs.SecDefs, secDefChunkSize+5)
+	assert.Len(t, secDefs.Missing, secDefChunkSize+5)
+	assert.Equal(t, 1, secDefs.Missing[0])
+}
+
+func TestAllConidsIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	exchangeConids, err := c.AllConids(AllConidsInput{Exchange: "NASDAQ"})
+	assert.Nil(t, err)
+	assert.Greater(t, len(exchangeConids), 0)
+}
+
+func TestAllConidsUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get all conids")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get all conids",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read all conids")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read all conids",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/all_conids.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", allConidsPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.AllConids(AllConidsInput{})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestConidUnit(t *testing.T) {
+	var v struct {
+		Number Conid `json:"number"`
+		String Conid `json:"string"`
+	}
+	assert.Nil(t, json.Unmarshal([]byte(`{"number":265598,"string":"265598"}`), &v))
+	assert.Equal(t, Conid(265598), v.Number)
+	assert.Equal(t, Conid(265598), v.String)
+	assert.Equal(t, "265598", v.String.String())
+
+	err := json.Unmarshal([]byte(`{"string":"abc"}`), &v)
+	assertError(t, true, "invalid conid 'abc'", err)
+
+	err = json.Unmarshal([]byte(`{"number":true}`), &v)
+	assertError(t, true, "failed to find appropriate type", err)
+}
+
+func TestContractTypedFieldsUnit(t *testing.T) {
+	v, err := os.ReadFile("./testdata/contracts.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var contracts []Contract
+	if !assert.Nil(t, json.Unmarshal(v, &contracts)) {
+		t.FailNow()
+	}
+
+	conid, err := contracts[0].ContractID()
+	assert.Nil(t, err)
+	assert.Equal(t, Conid(265598), conid)
+	assert.Equal(t, Conid(265598), Position{Conid: 265598}.ContractID())
+
+	assert.Nil(t, contracts[0].Sections[0].MonthList())
+	assert.Equal(t, []string{"NOV23", "DEC23"}, contracts[0].Sections[1].MonthList()[:2])
+	assert.Equal(t, []string{"SMART", "AMEX"}, contracts[0].Sections[1].Exchanges()[:2])
+
+	v, err = os.ReadFile("./testdata/secdefinfo.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var infos []SecurityDefinitionInfo
+	if !assert.Nil(t, json.Unmarshal(v, &infos)) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, Conid(659248794), infos[0].ContractID())
+
+	multiplier, err := infos[0].MultiplierValue()
+	assert.Nil(t, err)
+	assert.Equal(t, 100.0, multiplier)
+
+	maturity, err := infos[0].Maturity()
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), maturity)
+
+	exchanges := infos[0].Exchanges()
+	assert.Equal(t, "SMART", exchanges[0])
+	assert.Equal(t, "IBUSOPT", exchanges[len(exchanges)-1])
+
+	_, err = SecurityDefinitionInfo{Multiplier: "x"}.MultiplierValue()
+	assertError(t, true, "invalid multiplier", err)
+
+	_, err = SecurityDefinitionInfo{MaturityDate: "DEC23"}.Maturity()
+	assertError(t, true, "invalid maturity date", err)
+}
+
+func TestSecDefRoundPriceUnit(t *testing.T) {
+	secDef := SecDef{IncrementRules: []IncrementRule{
+		{LowerEdge: 3, Increment: 0.05},
+		{LowerEdge: 0, Increment: 0.01},
+	}}
+
+	tests := []struct {
+		price float64
+		tick  float64
+		want  float64
+	}{
+		{1.234, 0.01, 1.23},
+		{1.235, 0.01, 1.24},
+		{2.999, 0.01, 3},
+		{3, 0.05, 3},
+		{187.26, 0.05, 187.25},
+		{187.28, 0.05, 187.3},
+		{-4.12, 0.05, -4.1},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.tick, secDef.TickSize(tc.price), tc.price)
+		assert.Equal(t, tc.want, secDef.RoundPrice(tc.price), tc.price)
+	}
+
+	assert.Equal(t, 187.257, SecDef{}.RoundPrice(187.257))
+}
@@ -0,0 +1,269 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	bookDataTopic            = "sbd"
+	unsubscribeBookDataTopic = "ubd"
+)
+
+// BookRow - Row of the depth ladder as sent by the gateway
+type BookRow struct {
+	Row      int
+	Price    float64
+	Exchange string
+	BidSize  float64
+	AskSize  float64
+	// Focus - the row at the top of the book where bids and asks meet
+	Focus bool
+}
+
+func (b *BookRow) UnmarshalJSON(v []byte) error {
+	var raw struct {
+		Row   int    `json:"row"`
+		Focus int    `json:"focus"`
+		Price string `json:"price"`
+		Bid   string `json:"bid"`
+		Ask   string `json:"ask"`
+	}
+	if err := json.Unmarshal(v, &raw); err != nil {
+		return err
+	}
+
+	row := BookRow{Row: raw.Row, Focus: raw.Focus == 1}
+
+	// prices of smart depth carry the exchange, e.g. 157.69 (NASDAQ)
+	price := strings.TrimSpace(raw.Price)
+	if i := strings.Index(price, "("); i >= 0 {
+		row.Exchange = strings.TrimSuffix(strings.TrimSpace(price[i+1:]), ")")
+		price = strings.TrimSpace(price[:i])
+	}
+
+	var err error
+	if row.Price, err = parseBookNumber(price); err != nil {
+		return errors.Wrapf(err, "invalid price of book row %d", raw.Row)
+	}
+	if row.BidSize, err = parseBookNumber(raw.Bid); err != nil {
+		return errors.Wrapf(err, "invalid bid size of book row %d", raw.Row)
+	}
+	if row.AskSize, err = parseBookNumber(raw.Ask); err != nil {
+		return errors.Wrapf(err, "invalid ask size of book row %d", raw.Row)
+	}
+
+	*b = row
+	return nil
+}
+
+func parseBookNumber(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if s == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+// BookLevel - Price level of an order book
+type BookLevel struct {
+	Price    float64
+	Size     float64
+	Exchange string
+}
+
+// OrderBook - Consistent snapshot of the depth of a contract
+type OrderBook struct {
+	Conid   int
+	Bids    []BookLevel
+	Asks    []BookLevel
+	Updated time.Time
+}
+
+// BestBid - gets the highest bid, false when there are no bids
+func (o OrderBook) BestBid() (BookLevel, bool) {
+	if len(o.Bids) == 0 {
+		return BookLevel{}, false
+	}
+
+	return o.Bids[0], true
+}
+
+// BestAsk - gets the lowest ask, false when there are no asks
+func (o OrderBook) BestAsk() (BookLevel, bool) {
+	if len(o.Asks) == 0 {
+		return BookLevel{}, false
+	}
+
+	return o.Asks[0], true
+}
+
+// BookEvent - Incremental update of an order book
+type BookEvent struct {
+	Conid int
+	// Reset - the book was cleared, sent on every (re)connect before the ladder is sent
+	Reset bool
+	Rows  []BookRow
+}
+
+/*
+BookSubscription - Streamed depth of a contract. The order book is rebuilt from
+the rows received and is available through Book, every update is also delivered
+on C. The oldest event is dropped when C is not drained.
+*/
+type BookSubscription struct {
+	AccountID string
+	Conid     int
+	Exchange  string
+	C         <-chan BookEvent
+
+	stream  *Stream
+	topic   string
+	mu      sync.Mutex
+	ch      chan BookEvent
+	closed  bool
+	rows    map[int]BookRow
+	updated time.Time
+}
+
+/*
+SubscribeBookData - Subscribes to the depth of a contract on an exchange, an empty
+exchange streams the smart depth of every exchange. Updates do not name the
+exchange, so books of a contract on several exchanges each receive all of them.
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Websocket
+*/
+func (s *Stream) SubscribeBookData(accountID string, conid int, exchange string) (*BookSubscription, error) {
+	route := fmt.Sprintf("%s+%s+%d", bookDataTopic, accountID, conid)
+	topic := route
+	if exchange != "" {
+		topic = fmt.Sprintf("%s+%s", route, exchange)
+	}
+
+	ch := make(chan BookEvent, streamBufferSize)
+	b := &BookSubscription{
+		AccountID: accountID,
+		Conid:     conid,
+		Exchange:  exchange,
+		C:         ch,
+		stream:    s,
+		topic:     topic,
+		ch:        ch,
+		rows:      map[int]BookRow{},
+	}
+
+	if err := s.add(&streamSubscription{
+		topic:       topic,
+		route:       route,
+		subscribe:   topic,
+		unsubscribe: fmt.Sprintf("%s+%s", unsubscribeBookDataTopic, accountID),
+		handle:      b.handle,
+		reset:       b.reset,
+		close:       b.close,
+	}); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Unsubscribe - stops the subscription and closes C, other books of the account are subscribed again
+func (b *BookSubscription) Unsubscribe() error {
+	return b.stream.remove(b.topic)
+}
+
+// Book - gets a consistent snapshot of the order book, bids descending and asks ascending
+func (b *BookSubscription) Book() OrderBook {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	book := OrderBook{Conid: b.Conid, Updated: b.updated}
+	for _, row := range b.rows {
+		if row.BidSize > 0 {
+			book.Bids = append(book.Bids, BookLevel{Price: row.Price, Size: row.BidSize, Exchange: row.Exchange})
+		}
+
+		if row.AskSize > 0 {
+			book.Asks = append(book.Asks, BookLevel{Price: row.Price, Size: row.AskSize, Exchange: row.Exchange})
+		}
+	}
+
+	sort.SliceStable(book.Bids, func(i, j int) bool { return book.Bids[i].Price > book.Bids[j].Price })
+	sort.SliceStable(book.Asks, func(i, j int) bool { return book.Asks[i].Price < book.Asks[j].Price })
+	return book
+}
+
+func (b *BookSubscription) handle(msg []byte) {
+	var update struct {
+		Data []BookRow `json:"data"`
+	}
+	if err := json.Unmarshal(msg, &update); err != nil {
+		b.stream.report(errors.Wrapf(err, "failed to decode '%s' update", b.topic))
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	for _, row := range update.Data {
+		// a row without a price has been removed from the ladder
+		if row.Price == 0 {
+			delete(b.rows, row.Row)
+			continue
+		}
+		b.rows[row.Row] = row
+	}
+	b.updated = nowFn()
+
+	b.send(BookEvent{Conid: b.Conid, Rows: update.Data})
+}
+
+func (b *BookSubscription) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.rows = map[int]BookRow{}
+	b.updated = nowFn()
+	b.send(BookEvent{Conid: b.Conid, Reset: true})
+}
+
+// send - delivers an event dropping the oldest to make room, b.mu must be held
+func (b *BookSubscription) send(event BookEvent) {
+	for {
+		select {
+		case b.ch <- event:
+			return
+		default:
+		}
+
+		select {
+		case <-b.ch:
+		default:
+		}
+	}
+}
+
+func (b *BookSubscription) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.closed {
+		b.closed = true
+		close(b.ch)
+	}
+}
@@ -0,0 +1,190 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBookRowUnmarshalUnit(t *testing.T) {
+	var rows []BookRow
+	err := json.Unmarshal([]byte(`[
+		{"row":0,"focus":0,"price":"157.71","ask":"1,200"},
+		{"row":1,"focus":1,"price":"157.69 (NASDAQ)","bid":"300"},
+		{"row":2,"price":"abc"}
+	]`), &rows)
+	assert.NotNil(t, err)
+
+	err = json.Unmarshal([]byte(`[
+		{"row":0,"focus":0,"price":"157.71","ask":"1,200"},
+		{"row":1,"focus":1,"price":"157.69 (NASDAQ)","bid":"300"}
+	]`), &rows)
+	assert.Nil(t, err)
+	assert.Equal(t, []BookRow{
+		{Row: 0, Price: 157.71, AskSize: 1200},
+		{Row: 1, Price: 157.69, Exchange: "NASDAQ", BidSize: 300, Focus: true},
+	}, rows)
+}
+
+func TestStreamSubscribeBookDataUnit(t *testing.T) {
+	oldReconnectInterval := streamReconnectInterval
+	streamReconnectInterval = 10 * time.Millisecond
+	defer func() { streamReconnectInterval = oldReconnectInterval }()
+
+	server := newTestStreamServer(t)
+	defer server.Close()
+
+	s := NewStream(server.url(), nil, nil)
+	defer s.Close()
+
+	assert.Nil(t, s.Connect())
+
+	sub, err := s.SubscribeBookData("DU123", 265598, "ISLAND")
+	assert.Nil(t, err)
+	server.expect(t, "sbd+DU123+265598+ISLAND")
+
+	next := func() BookEvent {
+		select {
+		case event := <-sub.C:
+			return event
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for book event")
+		}
+		return BookEvent{}
+	}
+
+	server.send(t, `{"topic":"sbd+DU123+265598","data":[
+		{"row":0,"focus":0,"price":"157.72","ask":"200"},
+		{"row":1,"focus":0,"price":"157.71","ask":"100"},
+		{"row":2,"focus":1,"price":"157.70","bid":"300"},
+		{"row":3,"focus":0,"price":"157.69","bid":"400"}
+	]}`)
+	assert.Len(t, next().Rows, 4)
+
+	book := sub.Book()
+	assert.Equal(t, []BookLevel{{Price: 157.70, Size: 300}, {Price: 157.69, Size: 400}}, book.Bids)
+	assert.Equal(t, []BookLevel{{Price: 157.71, Size: 100}, {Price: 157.72, Size: 200}}, book.Asks)
+
+	server.send(t, `{"topic":"sbd+DU123+265598","data":[
+		{"row":1,"focus":0,"price":"157.71","ask":"50"},
+		{"row":3,"focus":0,"price":""}
+	]}`)
+	event := next()
+	assert.False(t, event.Reset)
+	assert.Len(t, event.Rows, 2)
+
+	book = sub.Book()
+	bid, ok := book.BestBid()
+	assert.True(t, ok)
+	assert.Equal(t, BookLevel{Price: 157.70, Size: 300}, bid)
+	ask, ok := book.BestAsk()
+	assert.True(t, ok)
+	assert.Equal(t, BookLevel{Price: 157.71, Size: 50}, ask)
+	assert.Len(t, book.Bids, 1)
+
+	// the book is cleared and resubscribed on reconnect
+	server.drop()
+	server.expect(t, "sbd+DU123+265598+ISLAND")
+	assert.True(t, next().Reset)
+
+	book = sub.Book()
+	_, ok = book.BestBid()
+	assert.False(t, ok)
+	_, ok = book.BestAsk()
+	assert.False(t, ok)
+
+	assert.Nil(t, sub.Unsubscribe())
+	server.expect(t, "ubd+DU123")
+}
+
+func TestStreamUnsubscribeBookDataUnit(t *testing.T) {
+	server := newTestStreamServer(t)
+	defer server.Close()
+
+	s := NewStream(server.url(), nil, nil)
+	defer s.Close()
+
+	assert.Nil(t, s.Connect())
+
+	first, err := s.SubscribeBookData("DU123", 265598, "ISLAND")
+	assert.Nil(t, err)
+	server.expect(t, "sbd+DU123+265598+ISLAND")
+
+	second, err := s.SubscribeBookData("DU123", 8314, "")
+	assert.Nil(t, err)
+	server.expect(t, "sbd+DU123+8314")
+
+	other, err := s.SubscribeBookData("DU456", 265598, "")
+	assert.Nil(t, err)
+	server.expect(t, "sbd+DU456+265598")
+
+	// ubd stops every book of the account, the remaining one is subscribed again
+	assert.Nil(t, first.Unsubscribe())
+	server.expect(t, "ubd+DU123")
+	server.expect(t, "sbd+DU123+8314")
+
+	select {
+	case event := <-second.C:
+		assert.True(t, event.Reset)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for book reset")
+	}
+
+	_, ok := <-first.C
+	assert.False(t, ok)
+
+	server.send(t, `{"topic":"sbd+DU123+8314","data":[{"row":0,"focus":1,"price":"10.5","bid":"100"}]}`)
+	select {
+	case event := <-second.C:
+		assert.Len(t, event.Rows, 1)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for book event")
+	}
+
+	assert.Nil(t, second.Unsubscribe())
+	server.expect(t, "ubd+DU123")
+
+	assert.Nil(t, other.Unsubscribe())
+	server.expect(t, "ubd+DU456")
+}
+
+func TestStreamBookDataExchangesUnit(t *testing.T) {
+	server := newTestStreamServer(t)
+	defer server.Close()
+
+	s := NewStream(server.url(), nil, nil)
+	defer s.Close()
+
+	assert.Nil(t, s.Connect())
+
+	island, err := s.SubscribeBookData("DU123", 265598, "ISLAND")
+	assert.Nil(t, err)
+	server.expect(t, "sbd+DU123+265598+ISLAND")
+
+	arca, err := s.SubscribeBookData("DU123", 265598, "ARCA")
+	assert.Nil(t, err)
+	server.expect(t, "sbd+DU123+265598+ARCA")
+
+	_, err = s.SubscribeBookData("DU123", 265598, "ARCA")
+	assertError(t, true, "already subscribed to 'sbd+DU123+265598+ARCA'", err)
+
+	// updates do not name the exchange and reach both books
+	server.send(t, `{"topic":"sbd+DU123+265598","data":[{"row":0,"focus":1,"price":"157.70","bid":"300"}]}`)
+	for _, sub := range []*BookSubscription{island, arca} {
+		select {
+		case event := <-sub.C:
+			assert.Len(t, event.Rows, 1)
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for book event")
+		}
+	}
+
+	assert.Nil(t, island.Unsubscribe())
+	server.expect(t, "ubd+DU123")
+	server.expect(t, "sbd+DU123+265598+ARCA")
+
+	_, ok := <-island.C
+	assert.False(t, ok)
+}
@@ -0,0 +1,252 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	currencyPairsPath = "v1/api/iserver/currency/pairs"
+	exchangeRatePath  = "v1/api/iserver/exchangerate"
+)
+
+/*
+CurrencyPair -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1currency~1pairs/get
+*/
+type CurrencyPair struct {
+	Symbol  string `json:"symbol"`
+	Conid   int    `json:"conid"`
+	CcyPair string `json:"ccyPair"`
+}
+
+/*
+CurrencyPairs - Currency pairs keyed by the base currency
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1currency~1pairs/get
+*/
+type CurrencyPairs map[string][]CurrencyPair
+
+/*
+ExchangeRateInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1exchangerate/get
+*/
+type ExchangeRateInput struct {
+	Source string
+	Target string
+}
+
+func (e ExchangeRateInput) toQuery() []query {
+	return []query{
+		{
+			key:   "source",
+			value: e.Source,
+		},
+		{
+			key:   "target",
+			value: e.Target,
+		},
+	}
+}
+
+/*
+ExchangeRate -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1exchangerate/get
+*/
+type ExchangeRate struct {
+	Rate float64 `json:"rate"`
+}
+
+// CashContract - CASH contract trading a currency pair
+type CashContract struct {
+	CurrencyPair
+	Base  string
+	Quote string
+	// Inverted - the contract quotes Quote.Base rather than Base.Quote
+	Inverted bool
+}
+
+/*
+ResolveCashContract - Finds the CASH contract of a currency pair. When the pair
+is only listed the other way round the inverted contract is returned.
+*/
+func ResolveCashContract(c Client, base, quote string) (*CashContract, error) {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+
+	for _, lookup := range []struct {
+		base, quote string
+		inverted    bool
+	}{
+		{base, quote, false},
+		{quote, base, true},
+	} {
+		pairs, err := c.CurrencyPairs(lookup.base)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, pair := range pairs[lookup.base] {
+			if strings.EqualFold(pair.CcyPair, lookup.quote) {
+				return &CashContract{
+					CurrencyPair: pair,
+					Base:         base,
+					Quote:        quote,
+					Inverted:     lookup.inverted,
+				}, nil
+			}
+		}
+	}
+
+	return nil, errors.Errorf("no cash contract found for %s.%s", base, quote)
+}
+
+type cachedRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+/*
+Converter - Converts amounts into a reporting currency, caching exchange rates for TTL
+*/
+type Converter struct {
+	client    Client
+	currency  string
+	ttl       time.Duration
+	mu        sync.Mutex
+	rateCache map[string]cachedRate
+}
+
+// NewConverter - returns a Converter to the reporting currency caching rates for ttl
+func NewConverter(c Client, reportingCurrency string, ttl time.Duration) *Converter {
+	return &Converter{
+		client:    c,
+		currency:  strings.ToUpper(reportingCurrency),
+		ttl:       ttl,
+		rateCache: map[string]cachedRate{},
+	}
+}
+
+// Currency - the reporting currency
+func (c *Converter) Currency() string {
+	return c.currency
+}
+
+// Rate - gets the exchange rate from source to target
+func (c *Converter) Rate(source, target string) (float64, error) {
+	source, target = strings.ToUpper(source), strings.ToUpper(target)
+	if source == target {
+		return 1, nil
+	}
+
+	key := source + "." + target
+	now := nowFn()
+
+	c.mu.Lock()
+	cached, ok := c.rateCache[key]
+	c.mu.Unlock()
+	if ok && now.Sub(cached.fetchedAt) < c.ttl {
+		return cached.rate, nil
+	}
+
+	rate, err := c.client.ExchangeRate(ExchangeRateInput{Source: source, Target: target})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get exchange rate %s", key)
+	}
+
+	if rate.Rate <= 0 {
+		return 0, errors.Errorf("invalid exchange rate %s: %v", key, rate.Rate)
+	}
+
+	c.mu.Lock()
+	c.rateCache[key] = cachedRate{rate: rate.Rate, fetchedAt: now}
+	c.mu.Unlock()
+
+	return rate.Rate, nil
+}
+
+// Convert - converts an amount in currency into the reporting currency
+func (c *Converter) Convert(amount float64, currency string) (float64, error) {
+	if currency == "" {
+		return 0, errors.New("amount has no currency")
+	}
+
+	rate, err := c.Rate(currency, c.currency)
+	if err != nil {
+		return 0, err
+	}
+
+	return amount * rate, nil
+}
+
+// ConvertSummary - converts the amount of an account summary value into the reporting currency
+func (c *Converter) ConvertSummary(a AccountSummaryInner) (float64, error) {
+	return c.Convert(a.Amount, a.Currency)
+}
+
+// ConvertPosition - converts the market value of a position into the reporting currency
+func (c *Converter) ConvertPosition(p Position) (float64, error) {
+	return c.Convert(p.MktValue, p.Currency)
+}
+
+/*
+CurrencyPairs - Gets the currency pairs available for a base currency
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1currency~1pairs/get
+*/
+func (c *client) CurrencyPairs(currency string) (CurrencyPairs, error) {
+	resp, err := c.get(currencyPairsPath, query{
+		key:   "currency",
+		value: currency,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var currencyPairs CurrencyPairs
+	if err := json.Unmarshal(v, &currencyPairs); err != nil {
+		return nil, err
+	}
+
+	return currencyPairs, nil
+}
+
+/*
+ExchangeRate - Gets the exchange rate from the source to the target currency
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1iserver~1exchangerate/get
+*/
+func (c *client) ExchangeRate(input ExchangeRateInput) (*ExchangeRate, error) {
+	resp, err := c.get(exchangeRatePath, input.toQuery()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var exchangeRate ExchangeRate
+	if err := json.Unmarshal(v, &exchangeRate); err != nil {
+		return nil, err
+	}
+
+	return &exchangeRate, nil
+}
@@ -0,0 +1,324 @@
+package ibweb
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCurrencyPairsIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	pairs, err := c.CurrencyPairs("USD")
+	assert.Nil(t, err)
+	assert.Greater(t, len(pairs["USD"]), 0)
+}
+
+func TestCurrencyPairsUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get currency pairs")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get currency pairs",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read currency pairs")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read currency pairs",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/currency_pairs.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", currencyPairsPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.CurrencyPairs("USD")
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestExchangeRateIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	rate, err := c.ExchangeRate(ExchangeRateInput{Source: "USD", Target: "EUR"})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Greater(t, rate.Rate, 0.0)
+}
+
+func TestExchangeRateUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get exchange rate")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get exchange rate",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read exchange rate")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read exchange rate",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/exchange_rate.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", exchangeRatePath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.ExchangeRate(ExchangeRateInput{Source: "USD", Target: "EUR"})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestResolveCashContractUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", currencyPairsPath),
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("currency") != "USD" {
+				return httpmock.NewStringResponse(200, "{}"), nil
+			}
+
+			return httpmock.NewStringResponse(200, httpmock.File("./testdata/currency_pairs.json").String()), nil
+		})
+
+	c := New("http://127.0.0.1:5555")
+
+	cash, err := ResolveCashContract(c, "usd", "jpy")
+	if assert.Nil(t, err) {
+		assert.Equal(t, 15016059, cash.Conid)
+		assert.False(t, cash.Inverted)
+	}
+
+	cash, err = ResolveCashContract(c, "CAD", "USD")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "USD.CAD", cash.Symbol)
+		assert.Equal(t, "CAD", cash.Base)
+		assert.True(t, cash.Inverted)
+	}
+
+	_, err = ResolveCashContract(c, "EUR", "GBP")
+	assertError(t, true, "no cash contract found for EUR.GBP", err)
+}
+
+func TestConverterUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	now := time.Date(2023, 11, 27, 0, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return now }
+	defer func() { nowFn = time.Now }()
+
+	rateURL := fmt.Sprintf("http://127.0.0.1:5555/%s", exchangeRatePath)
+	httpmock.RegisterResponder(http.MethodGet, rateURL,
+		func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("source") == "XXX" {
+				return httpmock.NewStringResponse(200, `{"rate":0}`), nil
+			}
+
+			return httpmock.NewStringResponse(200, `{"rate":0.5}`), nil
+		})
+	rateCalls := func() int {
+		return httpmock.GetCallCountInfo()[http.MethodGet+" "+rateURL]
+	}
+
+	converter := NewConverter(New("http://127.0.0.1:5555"), "eur", time.Minute)
+	assert.Equal(t, "EUR", converter.Currency())
+
+	v, err := converter.ConvertPosition(Position{MktValue: 1184.27, Currency: "USD"})
+	assert.Nil(t, err)
+	assert.InDelta(t, 592.135, v, 1e-9)
+
+	v, err = converter.ConvertSummary(AccountSummaryInner{Amount: 100, Currency: "USD"})
+	assert.Nil(t, err)
+	assert.Equal(t, 50.0, v)
+	assert.Equal(t, 1, rateCalls())
+
+	v, err = converter.Convert(100, "EUR")
+	assert.Nil(t, err)
+	assert.Equal(t, 100.0, v)
+	assert.Equal(t, 1, rateCalls())
+
+	now = now.Add(2 * time.Minute)
+	_, err = converter.Convert(100, "USD")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, rateCalls())
+
+	_, err = converter.Convert(100, "")
+	assertError(t, true, "amount has no currency", err)
+
+	_, err = converter.Convert(100, "XXX")
+	assertError(t, true, "invalid exchange rate XXX.EUR", err)
+}
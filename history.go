@@ -0,0 +1,357 @@
+package ibweb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// maxHistoryPoints - most bars the gateway returns for a history request
+	maxHistoryPoints = 1000
+)
+
+var (
+	// historyPacingInterval - wait between history requests
+	historyPacingInterval = 500 * time.Millisecond
+	// historyPacingBackoff - initial wait after a pacing violation, doubled per attempt
+	historyPacingBackoff = 10 * time.Second
+	// historyAttempts - number of requests made for a window before failing
+	historyAttempts = 5
+
+	historyBarRegexp = regexp.MustCompile(`^(\d+)(min|h|d|w|m)$`)
+)
+
+var historyBarUnits = map[string]time.Duration{
+	"min": time.Minute,
+	"h":   time.Hour,
+	"d":   24 * time.Hour,
+	"w":   7 * 24 * time.Hour,
+	"m":   30 * 24 * time.Hour,
+}
+
+// historyPeriodUnits - period units from largest to smallest with the most of each the gateway accepts
+var historyPeriodUnits = []struct {
+	unit     string
+	duration time.Duration
+	max      int
+}{
+	{"y", 365 * 24 * time.Hour, 15},
+	{"d", 24 * time.Hour, 1000},
+	{"h", time.Hour, 8},
+	{"min", time.Minute, 30},
+}
+
+/*
+historyPeriod - gets the longest period of whole units that fits within
+maxHistoryPoints bars of size bar and the limit of its unit, e.g. 3d for 5min
+bars and 8h for 1min bars, along with its duration
+*/
+func historyPeriod(bar string) (string, time.Duration, error) {
+	match := historyBarRegexp.FindStringSubmatch(bar)
+	if match == nil {
+		return "", 0, errors.Errorf("invalid bar '%s'", bar)
+	}
+
+	n, err := strconv.Atoi(match[1])
+	if err != nil || n == 0 {
+		return "", 0, errors.Errorf("invalid bar '%s'", bar)
+	}
+
+	span := time.Duration(maxHistoryPoints*n) * historyBarUnits[match[2]]
+	for _, u := range historyPeriodUnits {
+		count := int(span / u.duration)
+		if count < 1 {
+			continue
+		}
+		if count > u.max {
+			count = u.max
+		}
+
+		return strconv.Itoa(count) + u.unit, time.Duration(count) * u.duration, nil
+	}
+
+	return "", 0, errors.Errorf("invalid bar '%s'", bar)
+}
+
+// historyUnavailable - the gateway answered that it has no data for the window
+func historyUnavailable(err error) bool {
+	var statusErr StatusCodeError
+	if !errors.As(err, &statusErr) || statusErr.Err == nil {
+		return false
+	}
+
+	msg := strings.ToLower(statusErr.Err.Error())
+	return strings.Contains(msg, "no data") || strings.Contains(msg, "data unavailable")
+}
+
+/*
+HistoryDownload - Download of the bars of a contract between From and To split
+into windows the gateway accepts. Windows are requested backwards from To, the
+progress is kept in Cursor and Bars so that Run resumes where it failed. When
+CheckpointPath is set the cursor is also saved there after each window, with the
+bars of the window appended to CheckpointPath.bars as JSON lines, and both are
+loaded by Run.
+*/
+type HistoryDownload struct {
+	Conid      int       `json:"conid"`
+	Bar        string    `json:"bar"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	Exchange   string    `json:"exchange,omitempty"`
+	OutsideRth bool      `json:"outsideRth,omitempty"`
+
+	// Cursor - end of the next window, zero before the first window
+	Cursor time.Time `json:"cursor"`
+	Done   bool      `json:"done"`
+	// Bars - bars downloaded so far ordered by time, saved apart from the checkpoint
+	Bars []Bar `json:"-"`
+
+	CheckpointPath string `json:"-"`
+
+	// barsSize - bytes of the bars file covered by the checkpoint
+	barsSize int64
+}
+
+// historyCheckpoint - saved progress of a HistoryDownload
+type historyCheckpoint struct {
+	*HistoryDownload
+	// BarsSize - bytes of the bars file written before the checkpoint, later bytes are of an interrupted write
+	BarsSize int64 `json:"barsSize"`
+}
+
+// NewHistoryDownload - returns a download of conid bars from from to to
+func NewHistoryDownload(conid int, bar string, from, to time.Time) *HistoryDownload {
+	return &HistoryDownload{
+		Conid: conid,
+		Bar:   bar,
+		From:  from,
+		To:    to,
+	}
+}
+
+/*
+DownloadHistory - Downloads the bars of a contract from from to to, inclusive,
+in UTC. Use HistoryDownload to resume failed downloads.
+*/
+func DownloadHistory(c Client, conid int, bar string, from, to time.Time) ([]Bar, error) {
+	h := NewHistoryDownload(conid, bar, from, to)
+	if err := h.Run(c); err != nil {
+		return nil, err
+	}
+
+	return h.Bars, nil
+}
+
+/*
+Run - Downloads the remaining windows until From or until the gateway answers
+that no data is available. Windows without bars, e.g. overnight or over a
+weekend, are stepped over and bars overlapping earlier windows are dropped.
+*/
+func (h *HistoryDownload) Run(c Client) error {
+	if !h.From.Before(h.To) {
+		return errors.Errorf("invalid history range %s - %s", h.From, h.To)
+	}
+
+	period, span, err := historyPeriod(h.Bar)
+	if err != nil {
+		return err
+	}
+
+	if err := h.loadCheckpoint(); err != nil {
+		return err
+	}
+
+	if h.Cursor.IsZero() {
+		h.Cursor = h.To
+	}
+
+	seen := map[int64]bool{}
+	for _, bar := range h.Bars {
+		seen[bar.Time.UnixMilli()] = true
+	}
+
+	for !h.Done {
+		history, err := h.window(c, period)
+		if historyUnavailable(err) {
+			// the start of the available history was reached
+			h.Done = true
+			if err := h.saveCheckpoint(nil); err != nil {
+				return err
+			}
+			break
+		}
+		if err != nil {
+			if saveErr := h.saveCheckpoint(nil); saveErr != nil {
+				return errors.Wrap(saveErr, err.Error())
+			}
+			return errors.Wrapf(err, "failed to download history of conid '%d' before %s", h.Conid, h.Cursor)
+		}
+
+		var added []Bar
+		earliest := h.Cursor
+		for _, bar := range history.Bars(time.UTC) {
+			if bar.Time.Before(earliest) {
+				earliest = bar.Time
+			}
+
+			if bar.Time.Before(h.From) || bar.Time.After(h.To) || seen[bar.Time.UnixMilli()] {
+				continue
+			}
+
+			seen[bar.Time.UnixMilli()] = true
+			added = append(added, bar)
+		}
+		h.Bars = append(h.Bars, added...)
+		h.Bars = cleanBars(h.Bars)
+
+		// a window without earlier bars falls in a gap, e.g. overnight or a
+		// weekend, so the next window starts a whole period earlier
+		if earliest.Before(h.Cursor) {
+			h.Cursor = earliest
+		} else {
+			h.Cursor = h.Cursor.Add(-span)
+		}
+		if !h.Cursor.After(h.From) {
+			h.Done = true
+		}
+
+		if err := h.saveCheckpoint(added); err != nil {
+			return err
+		}
+
+		if !h.Done {
+			sleepFn(historyPacingInterval)
+		}
+	}
+
+	return nil
+}
+
+// window - requests the bars of the window ending at Cursor, waiting out pacing violations
+func (h *HistoryDownload) window(c Client, period string) (*MarketDataHistory, error) {
+	backoff := historyPacingBackoff
+
+	var err error
+	for attempt := 0; attempt < historyAttempts; attempt++ {
+		var history *MarketDataHistory
+		history, err = c.MarketDataHistory(MarketDataHistoryInput{
+			ConID:      strconv.Itoa(h.Conid),
+			Exchange:   h.Exchange,
+			Period:     period,
+			Bar:        h.Bar,
+			OutsideRth: h.OutsideRth,
+			StartTime:  h.Cursor,
+		})
+		if err == nil {
+			return history, nil
+		}
+
+		var statusErr StatusCodeError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests {
+			return nil, err
+		}
+
+		sleepFn(backoff)
+		backoff *= 2
+	}
+
+	return nil, err
+}
+
+func (h *HistoryDownload) loadCheckpoint() error {
+	if h.CheckpointPath == "" {
+		return nil
+	}
+
+	v, err := os.ReadFile(h.CheckpointPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	checkpoint := historyCheckpoint{HistoryDownload: &HistoryDownload{}}
+	if err := json.Unmarshal(v, &checkpoint); err != nil {
+		return errors.Wrapf(err, "failed to read history checkpoint '%s'", h.CheckpointPath)
+	}
+
+	if checkpoint.Conid != h.Conid || checkpoint.Bar != h.Bar || !checkpoint.From.Equal(h.From) || !checkpoint.To.Equal(h.To) {
+		return errors.Errorf("history checkpoint '%s' is of a different download", h.CheckpointPath)
+	}
+
+	var bars []Bar
+	if checkpoint.BarsSize > 0 {
+		f, err := os.Open(h.barsPath())
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if bars, err = ReadBarsJSONL(io.LimitReader(f, checkpoint.BarsSize)); err != nil {
+			return errors.Wrapf(err, "failed to read history bars '%s'", h.barsPath())
+		}
+	}
+
+	h.Cursor = checkpoint.Cursor
+	h.Done = checkpoint.Done
+	h.Bars = cleanBars(bars)
+	h.barsSize = checkpoint.BarsSize
+	return nil
+}
+
+/*
+saveCheckpoint - appends the bars added by the last window to the bars file,
+dropping anything past the previous checkpoint, then saves the cursor
+*/
+func (h *HistoryDownload) saveCheckpoint(added []Bar) error {
+	if h.CheckpointPath == "" {
+		return nil
+	}
+
+	if len(added) > 0 {
+		var buf bytes.Buffer
+		if err := WriteBarsJSONL(&buf, added); err != nil {
+			return err
+		}
+
+		f, err := os.OpenFile(h.barsPath(), os.O_WRONLY|os.O_CREATE, 0o644)
+		if err != nil {
+			return err
+		}
+
+		if err := f.Truncate(h.barsSize); err != nil {
+			f.Close()
+			return err
+		}
+
+		n, err := f.WriteAt(buf.Bytes(), h.barsSize)
+		if err != nil {
+			f.Close()
+			return err
+		}
+
+		if err := f.Close(); err != nil {
+			return err
+		}
+		h.barsSize += int64(n)
+	}
+
+	return writeFileAtomic(h.CheckpointPath, func(w io.Writer) error {
+		return json.NewEncoder(w).Encode(historyCheckpoint{HistoryDownload: h, BarsSize: h.barsSize})
+	})
+}
+
+// barsPath - file the downloaded bars are appended to
+func (h *HistoryDownload) barsPath() string {
+	return h.CheckpointPath + ".bars"
+}
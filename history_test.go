@@ -0,0 +1,268 @@
+package ibweb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryPeriodUnit(t *testing.T) {
+	tests := []struct {
+		bar      string
+		want     string
+		wantSpan time.Duration
+		wantErr  bool
+	}{
+		{bar: "1min", want: "8h", wantSpan: 8 * time.Hour},
+		{bar: "2min", want: "1d", wantSpan: 24 * time.Hour},
+		{bar: "5min", want: "3d", wantSpan: 3 * 24 * time.Hour},
+		{bar: "1h", want: "41d", wantSpan: 41 * 24 * time.Hour},
+		{bar: "1d", want: "2y", wantSpan: 2 * 365 * 24 * time.Hour},
+		{bar: "1m", want: "15y", wantSpan: 15 * 365 * 24 * time.Hour},
+		{bar: "5s", wantErr: true},
+		{bar: "0min", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		got, span, err := historyPeriod(tc.bar)
+		if tc.wantErr {
+			assert.NotNil(t, err, tc.bar)
+			continue
+		}
+
+		assert.Nil(t, err, tc.bar)
+		assert.Equal(t, tc.want, got, tc.bar)
+		assert.Equal(t, tc.wantSpan, span, tc.bar)
+	}
+}
+
+// registerHistoryGateway - serves 5min bars from available, four per request
+// ending at startTime so consecutive windows overlap by a bar
+func registerHistoryGateway(available time.Time, fail func(call int) int) *[]string {
+	var startTimes []string
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataHistory),
+		func(req *http.Request) (*http.Response, error) {
+			startTimes = append(startTimes, req.URL.Query().Get("startTime"))
+			if status := fail(len(startTimes)); status != 0 {
+				return httpmock.NewStringResponse(status, `{"error":"failed"}`), nil
+			}
+
+			end, err := time.Parse(historyStartTimeLayout, req.URL.Query().Get("startTime"))
+			if err != nil {
+				return httpmock.NewStringResponse(400, `{"error":"bad startTime"}`), nil
+			}
+
+			var data []HistoryBar
+			for t := end.Add(-15 * time.Minute); !t.After(end); t = t.Add(5 * time.Minute) {
+				if t.Before(available) {
+					continue
+				}
+				data = append(data, HistoryBar{O: 1, H: 2, L: 0.5, C: 1.5, V: 10, T: t.UnixMilli()})
+			}
+
+			return httpmock.NewJsonResponse(200, MarketDataHistory{VolumeFactor: 1, Data: data})
+		})
+
+	return &startTimes
+}
+
+func TestDownloadHistoryUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	var slept []time.Duration
+	sleepFn = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleepFn = time.Sleep }()
+
+	available := time.Date(2023, 11, 27, 14, 30, 0, 0, time.UTC)
+	startTimes := registerHistoryGateway(available, func(call int) int {
+		// the second request violates pacing
+		if call == 2 {
+			return http.StatusTooManyRequests
+		}
+		return 0
+	})
+
+	c := New("http://127.0.0.1:5555")
+	from, to := available.Add(10*time.Minute), available.Add(time.Hour)
+
+	bars, err := DownloadHistory(c, 265598, "5min", from, to)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	if assert.Len(t, bars, 11) {
+		for i, bar := range bars {
+			assert.Equal(t, from.Add(time.Duration(i)*5*time.Minute), bar.Time)
+		}
+	}
+
+	assert.Equal(t, []string{
+		"20231127-15:30:00",
+		"20231127-15:15:00",
+		"20231127-15:15:00",
+		"20231127-15:00:00",
+		"20231127-14:45:00",
+	}, *startTimes)
+	assert.Contains(t, slept, historyPacingBackoff)
+
+	_, err = DownloadHistory(c, 265598, "5min", to, from)
+	assert.NotNil(t, err)
+
+	_, err = DownloadHistory(c, 265598, "5s", from, to)
+	assert.NotNil(t, err)
+}
+
+func TestHistoryDownloadCheckpointUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	sleepFn = func(time.Duration) {}
+	defer func() { sleepFn = time.Sleep }()
+
+	available := time.Date(2023, 11, 27, 14, 30, 0, 0, time.UTC)
+	failing := true
+	startTimes := registerHistoryGateway(available, func(call int) int {
+		if failing && call == 3 {
+			return http.StatusInternalServerError
+		}
+		return 0
+	})
+
+	c := New("http://127.0.0.1:5555")
+	from, to := available.Add(10*time.Minute), available.Add(time.Hour)
+	path := filepath.Join(t.TempDir(), "history.json")
+
+	h := NewHistoryDownload(265598, "5min", from, to)
+	h.CheckpointPath = path
+	assert.NotNil(t, h.Run(c))
+	assert.Len(t, h.Bars, 7)
+
+	// the checkpoint keeps the cursor, the bars are appended to a file of their own
+	v, err := os.ReadFile(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.NotContains(t, string(v), "bars\":[")
+
+	f, err := os.Open(path + ".bars")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	saved, err := ReadBarsJSONL(f)
+	f.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, h.Bars, cleanBars(saved))
+
+	// bytes of a write interrupted after the checkpoint are dropped
+	f, err = os.OpenFile(path+".bars", os.O_APPEND|os.O_WRONLY, 0)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	_, err = f.WriteString(`{"time":"2023-11-27T15`)
+	assert.Nil(t, err)
+	f.Close()
+
+	// a new download resumes from the saved cursor
+	failing = false
+	resumed := NewHistoryDownload(265598, "5min", from, to)
+	resumed.CheckpointPath = path
+	if !assert.Nil(t, resumed.Run(c)) {
+		t.FailNow()
+	}
+	assert.Len(t, resumed.Bars, 11)
+	assert.True(t, resumed.Done)
+	assert.Equal(t, "20231127-15:00:00", (*startTimes)[3])
+
+	reloaded := NewHistoryDownload(265598, "5min", from, to)
+	reloaded.CheckpointPath = path
+	if assert.Nil(t, reloaded.Run(c)) {
+		assert.Equal(t, resumed.Bars, reloaded.Bars)
+	}
+
+	other := NewHistoryDownload(265598, "1h", from, to)
+	other.CheckpointPath = path
+	assert.NotNil(t, other.Run(c))
+}
+
+// registerSessionGateway - serves the 1min bars of sessions within the requested
+// period ending at startTime, answering that no data is available before the first session
+func registerSessionGateway(sessions [][2]time.Time) *[]string {
+	var startTimes []string
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataHistory),
+		func(req *http.Request) (*http.Response, error) {
+			startTimes = append(startTimes, req.URL.Query().Get("startTime"))
+
+			end, err := time.Parse(historyStartTimeLayout, req.URL.Query().Get("startTime"))
+			if err != nil {
+				return httpmock.NewStringResponse(400, `{"error":"bad startTime"}`), nil
+			}
+			if !end.After(sessions[0][0]) {
+				return httpmock.NewStringResponse(500, `{"error":"Chart data unavailable"}`), nil
+			}
+
+			_, span, err := historyPeriod(req.URL.Query().Get("bar"))
+			if err != nil || req.URL.Query().Get("period") != "8h" {
+				return httpmock.NewStringResponse(400, `{"error":"bad period"}`), nil
+			}
+
+			var data []HistoryBar
+			for _, session := range sessions {
+				for t := session[0]; t.Before(session[1]); t = t.Add(time.Minute) {
+					if t.After(end.Add(-span)) && !t.After(end) {
+						data = append(data, HistoryBar{O: 1, H: 2, L: 0.5, C: 1.5, V: 10, T: t.UnixMilli()})
+					}
+				}
+			}
+
+			return httpmock.NewJsonResponse(200, MarketDataHistory{VolumeFactor: 1, Data: data})
+		})
+
+	return &startTimes
+}
+
+func TestDownloadHistoryGapsUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	sleepFn = func(time.Duration) {}
+	defer func() { sleepFn = time.Sleep }()
+
+	session := func(day int) [2]time.Time {
+		open := time.Date(2023, 11, day, 14, 30, 0, 0, time.UTC)
+		return [2]time.Time{open, open.Add(390 * time.Minute)}
+	}
+	// Thursday and Friday then Monday, leaving an overnight and a weekend gap
+	// longer than the 8h window of 1min bars
+	startTimes := registerSessionGateway([][2]time.Time{session(23), session(24), session(27)})
+
+	c := New("http://127.0.0.1:5555")
+
+	bars, err := DownloadHistory(c, 265598, "1min", time.Date(2023, 11, 23, 0, 0, 0, 0, time.UTC), session(27)[1])
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	if assert.Len(t, bars, 3*390) {
+		assert.Equal(t, session(23)[0], bars[0].Time)
+		assert.Equal(t, session(27)[1].Add(-time.Minute), bars[len(bars)-1].Time)
+	}
+	// the walk ends on the no data answer at the first session
+	assert.Equal(t, "20231123-14:30:00", (*startTimes)[len(*startTimes)-1])
+
+	// a range ending in a gap is walked back to From
+	bars, err = DownloadHistory(c, 265598, "1min", session(24)[0], time.Date(2023, 11, 26, 0, 0, 0, 0, time.UTC))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.Len(t, bars, 390)
+}
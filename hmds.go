@@ -0,0 +1,138 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	hmdsHistoryPath = "v1/api/hmds/history"
+)
+
+// HMDSBarType - Data the bars of an HMDS history request are built from
+type HMDSBarType string
+
+const (
+	BarTypeLast      HMDSBarType = "Last"
+	BarTypeBid       HMDSBarType = "Bid"
+	BarTypeAsk       HMDSBarType = "Ask"
+	BarTypeMidpoint  HMDSBarType = "Midpoint"
+	BarTypeFeeRate   HMDSBarType = "FeeRate"
+	BarTypeInventory HMDSBarType = "Inventory"
+)
+
+// HistoryDirection - Direction an HMDS history request walks from its StartTime
+type HistoryDirection int
+
+const (
+	HistoryBackward HistoryDirection = -1
+	HistoryForward  HistoryDirection = 1
+)
+
+/*
+HMDSHistoryInput - Zero values are left to the gateway defaults, Last bars
+walking backward from now. There is no option for adjusted or unadjusted bars:
+the endpoint documents no such parameter and the Client Portal API offers no
+adjusted bar type like ADJUSTED_LAST of the TWS API. Bars are returned as the
+gateway serves them and which corporate actions are applied to them is not
+documented, so check a known split before relying on either.
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1hmds~1history/get
+*/
+type HMDSHistoryInput struct {
+	Conid      int
+	Period     string
+	Bar        string
+	BarType    HMDSBarType
+	OutsideRth bool
+	StartTime  time.Time
+	Direction  HistoryDirection
+}
+
+func (h HMDSHistoryInput) toQuery() []query {
+	queries := []query{
+		{
+			key:   "conid",
+			value: strconv.Itoa(h.Conid),
+		},
+	}
+
+	if h.Period != "" {
+		queries = append(queries, query{
+			key:   "period",
+			value: h.Period,
+		})
+	}
+
+	if h.Bar != "" {
+		queries = append(queries, query{
+			key:   "bar",
+			value: h.Bar,
+		})
+	}
+
+	if h.BarType != "" {
+		queries = append(queries, query{
+			key:   "barType",
+			value: string(h.BarType),
+		})
+	}
+
+	if h.OutsideRth {
+		queries = append(queries, query{
+			key:   "outsideRth",
+			value: "true",
+		})
+	}
+
+	if !h.StartTime.IsZero() {
+		queries = append(queries, query{
+			key:   "startTime",
+			value: h.StartTime.UTC().Format(historyStartTimeLayout),
+		})
+	}
+
+	if h.Direction != 0 {
+		queries = append(queries, query{
+			key:   "direction",
+			value: strconv.Itoa(int(h.Direction)),
+		})
+	}
+
+	return queries
+}
+
+/*
+HMDSHistory - Gets historical bars of a contract from the historical market data
+service. The response has the shape of MarketDataHistory, so Bars, Highest and
+Lowest work the same for either source.
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1hmds~1history/get
+*/
+func (c *client) HMDSHistory(input HMDSHistoryInput) (*MarketDataHistory, error) {
+	resp, err := c.get(hmdsHistoryPath, input.toQuery()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var marketDataHistory MarketDataHistory
+	if err := json.Unmarshal(v, &marketDataHistory); err != nil {
+		return nil, err
+	}
+
+	if err := c.delayedDataPolicy.check(OperationHMDSHistory, input.Conid, marketDataHistory.Availability()); err != nil {
+		return nil, err
+	}
+
+	return &marketDataHistory, nil
+}
@@ -0,0 +1,194 @@
+package ibweb
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMDSHistoryIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	history, err := c.HMDSHistory(HMDSHistoryInput{
+		Conid:   265598,
+		Period:  "1d",
+		Bar:     "5min",
+		BarType: BarTypeMidpoint,
+	})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Greater(t, len(history.Bars(nil)), 0)
+}
+
+func TestHMDSHistoryUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get hmds history")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get hmds history",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read hmds history")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read hmds history",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/hmds_history.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", hmdsHistoryPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.HMDSHistory(HMDSHistoryInput{Conid: 265598, BarType: BarTypeBid})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestHMDSHistoryInputUnit(t *testing.T) {
+	var got url.Values
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	v, err := os.ReadFile("./testdata/hmds_history.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", hmdsHistoryPath),
+		func(req *http.Request) (*http.Response, error) {
+			got = req.URL.Query()
+			return httpmock.NewBytesResponse(200, v), nil
+		})
+
+	c := New("http://127.0.0.1:5555")
+	history, err := c.HMDSHistory(HMDSHistoryInput{
+		Conid:      265598,
+		Period:     "3min",
+		Bar:        "1min",
+		BarType:    BarTypeBid,
+		OutsideRth: true,
+		StartTime:  time.Date(2023, 11, 27, 15, 57, 0, 0, time.FixedZone("EST", -5*60*60)),
+		Direction:  HistoryForward,
+	})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, url.Values{
+		"conid":      {"265598"},
+		"period":     {"3min"},
+		"bar":        {"1min"},
+		"barType":    {"Bid"},
+		"outsideRth": {"true"},
+		"startTime":  {"20231127-20:57:00"},
+		"direction":  {"1"},
+	}, got)
+
+	bars := history.Bars(nil)
+	if assert.Len(t, bars, 3) {
+		assert.Equal(t, Bar{
+			Time:   time.UnixMilli(1701118680000).UTC(),
+			Open:   139.78,
+			High:   139.82,
+			Low:    139.77,
+			Close:  139.81,
+			Volume: 1200,
+		}, bars[1])
+	}
+
+	high, err := history.Highest()
+	assert.Nil(t, err)
+	assert.Equal(t, HistoryExtreme{Price: 139.82, Volume: 1200, Bar: 1}, high)
+}
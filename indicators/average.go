@@ -0,0 +1,103 @@
+package indicators
+
+// SMA - Simple moving average
+type SMA struct {
+	period int
+	window *window
+	count  int
+	sum    float64
+}
+
+// NewSMA - returns a simple moving average over period values
+func NewSMA(period int) *SMA {
+	checkPeriod(period)
+	return &SMA{period: period, window: newWindow(period)}
+}
+
+func (s *SMA) Update(v float64) float64 {
+	s.sum += v - s.window.push(v)
+	if s.count < s.period {
+		s.count++
+	}
+
+	if !s.Ready() {
+		return nan()
+	}
+
+	return s.sum / float64(s.period)
+}
+
+func (s *SMA) Ready() bool {
+	return s.count == s.period
+}
+
+/*
+EMA - Exponential moving average weighting values by 2/(period+1), seeded with
+the simple average of the first period values
+*/
+type EMA struct {
+	period int
+	alpha  float64
+	count  int
+	value  float64
+}
+
+// NewEMA - returns an exponential moving average over period values
+func NewEMA(period int) *EMA {
+	checkPeriod(period)
+	return &EMA{period: period, alpha: 2 / float64(period+1)}
+}
+
+func (e *EMA) Update(v float64) float64 {
+	if e.count < e.period {
+		e.count++
+		e.value += (v - e.value) / float64(e.count)
+		if !e.Ready() {
+			return nan()
+		}
+
+		return e.value
+	}
+
+	e.value += (v - e.value) * e.alpha
+	return e.value
+}
+
+func (e *EMA) Ready() bool {
+	return e.count == e.period
+}
+
+// WMA - Linearly weighted moving average, the latest value weighted period
+type WMA struct {
+	period int
+	window *window
+	count  int
+}
+
+// NewWMA - returns a weighted moving average over period values
+func NewWMA(period int) *WMA {
+	checkPeriod(period)
+	return &WMA{period: period, window: newWindow(period)}
+}
+
+func (w *WMA) Update(v float64) float64 {
+	w.window.push(v)
+	if w.count < w.period {
+		w.count++
+	}
+
+	if !w.Ready() {
+		return nan()
+	}
+
+	var sum float64
+	for i := 0; i < w.period; i++ {
+		sum += w.window.at(i) * float64(i+1)
+	}
+
+	return sum / float64(w.period*(w.period+1)/2)
+}
+
+func (w *WMA) Ready() bool {
+	return w.count == w.period
+}
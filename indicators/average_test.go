@@ -0,0 +1,21 @@
+package indicators
+
+import (
+	"testing"
+)
+
+func TestSMAUnit(t *testing.T) {
+	assertSeries(t, []float64{22.22, 22.21, 22.23}, Batch(NewSMA(10), emaPrices[:12]), 0.005)
+}
+
+func TestEMAUnit(t *testing.T) {
+	// StockCharts 10 day EMA
+	assertSeries(t, []float64{
+		22.22, 22.21, 22.24, 22.27, 22.33, 22.52, 22.80, 22.97, 23.13, 23.28, 23.34,
+		23.43, 23.51, 23.53, 23.47, 23.40, 23.39, 23.26, 23.23, 23.08, 22.92,
+	}, Batch(NewEMA(10), emaPrices), 0.005)
+}
+
+func TestWMAUnit(t *testing.T) {
+	assertSeries(t, []float64{22.164667, 22.148667, 22.175333, 22.266, 22.27}, Batch(NewWMA(5), emaPrices[:9]), 1e-6)
+}
@@ -0,0 +1,108 @@
+/*
+Package indicators - Technical indicators over ibweb bar series. Every indicator
+is updated one value or bar at a time so the same type serves streaming updates
+and, through Batch and the Series functions, whole series. Values are NaN until
+an indicator has seen enough data to be Ready.
+*/
+package indicators
+
+import (
+	"math"
+
+	"github.com/fincodetoad/ibweb"
+)
+
+// Indicator - Indicator updated one value at a time
+type Indicator interface {
+	// Update - adds a value and returns the indicator value, NaN until Ready
+	Update(v float64) float64
+	// Ready - the indicator has seen enough values
+	Ready() bool
+}
+
+// Batch - runs a new indicator over values, NaN where it is not ready
+func Batch(ind Indicator, values []float64) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = ind.Update(v)
+	}
+
+	return out
+}
+
+// Closes - gets the closing prices of bars
+func Closes(bars []ibweb.Bar) []float64 {
+	values := make([]float64, len(bars))
+	for i, bar := range bars {
+		values[i] = bar.Close
+	}
+
+	return values
+}
+
+// Highs - gets the high prices of bars
+func Highs(bars []ibweb.Bar) []float64 {
+	values := make([]float64, len(bars))
+	for i, bar := range bars {
+		values[i] = bar.High
+	}
+
+	return values
+}
+
+// Lows - gets the low prices of bars
+func Lows(bars []ibweb.Bar) []float64 {
+	values := make([]float64, len(bars))
+	for i, bar := range bars {
+		values[i] = bar.Low
+	}
+
+	return values
+}
+
+// window - fixed size ring of the latest values
+type window struct {
+	values []float64
+	next   int
+	full   bool
+}
+
+func newWindow(size int) *window {
+	return &window{values: make([]float64, size)}
+}
+
+// push - adds v and returns the value it replaced, zero until the window is full
+func (w *window) push(v float64) float64 {
+	old := w.values[w.next]
+	if !w.full {
+		old = 0
+	}
+
+	w.values[w.next] = v
+	w.next++
+	if w.next == len(w.values) {
+		w.next = 0
+		w.full = true
+	}
+
+	return old
+}
+
+// at - gets the i-th oldest value
+func (w *window) at(i int) float64 {
+	if !w.full {
+		return w.values[i]
+	}
+
+	return w.values[(w.next+i)%len(w.values)]
+}
+
+func nan() float64 {
+	return math.NaN()
+}
+
+func checkPeriod(period int) {
+	if period < 1 {
+		panic("indicators: period must be positive")
+	}
+}
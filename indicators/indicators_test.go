@@ -0,0 +1,64 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fincodetoad/ibweb"
+	"github.com/stretchr/testify/assert"
+)
+
+// emaPrices - closes of the StockCharts moving average example
+var emaPrices = []float64{
+	22.27, 22.19, 22.08, 22.17, 22.18, 22.13, 22.23, 22.43, 22.24, 22.29,
+	22.15, 22.39, 22.38, 22.61, 23.36, 24.05, 23.75, 23.83, 23.95, 23.63,
+	23.82, 23.87, 23.65, 23.19, 23.10, 23.33, 22.68, 23.10, 22.40, 22.17,
+}
+
+// assertSeries - asserts the values after the NaN warm up match want within delta
+func assertSeries(t *testing.T, want []float64, got []float64, delta float64) {
+	t.Helper()
+
+	warmUp := len(got) - len(want)
+	for i, v := range got {
+		if i < warmUp {
+			assert.True(t, math.IsNaN(v), "value %d should be NaN, got %v", i, v)
+			continue
+		}
+		assert.InDelta(t, want[i-warmUp], v, delta, "value %d", i)
+	}
+}
+
+func TestBatchUnit(t *testing.T) {
+	bars := []ibweb.Bar{
+		{Time: time.Unix(0, 0), High: 2, Low: 1, Close: 1.5},
+		{Time: time.Unix(60, 0), High: 3, Low: 2, Close: 2.5},
+	}
+	assert.Equal(t, []float64{1.5, 2.5}, Closes(bars))
+	assert.Equal(t, []float64{2, 3}, Highs(bars))
+	assert.Equal(t, []float64{1, 2}, Lows(bars))
+
+	// streaming updates match the batch
+	batch := Batch(NewSMA(3), emaPrices)
+	sma := NewSMA(3)
+	for i, v := range emaPrices {
+		got := sma.Update(v)
+		if math.IsNaN(batch[i]) {
+			assert.True(t, math.IsNaN(got))
+			continue
+		}
+		assert.Equal(t, batch[i], got)
+	}
+
+	assert.Panics(t, func() { NewSMA(0) })
+}
+
+func TestWindowUnit(t *testing.T) {
+	w := newWindow(3)
+	assert.Equal(t, 0.0, w.push(1))
+	assert.Equal(t, 0.0, w.push(2))
+	assert.Equal(t, 0.0, w.push(3))
+	assert.Equal(t, 1.0, w.push(4))
+	assert.Equal(t, []float64{2, 3, 4}, []float64{w.at(0), w.at(1), w.at(2)})
+}
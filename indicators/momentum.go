@@ -0,0 +1,115 @@
+package indicators
+
+import "math"
+
+/*
+RSI - Relative strength index using Wilder's smoothing, seeded with the simple
+average of the first period gains and losses
+*/
+type RSI struct {
+	period  int
+	count   int
+	prev    float64
+	avgGain float64
+	avgLoss float64
+}
+
+// NewRSI - returns a relative strength index over period changes
+func NewRSI(period int) *RSI {
+	checkPeriod(period)
+	return &RSI{period: period}
+}
+
+func (r *RSI) Update(v float64) float64 {
+	if r.count == 0 {
+		r.count++
+		r.prev = v
+		return nan()
+	}
+
+	change := v - r.prev
+	r.prev = v
+	gain, loss := math.Max(change, 0), math.Max(-change, 0)
+
+	n := float64(r.period)
+	if r.count <= r.period {
+		r.avgGain += gain / n
+		r.avgLoss += loss / n
+		r.count++
+	} else {
+		r.avgGain = (r.avgGain*(n-1) + gain) / n
+		r.avgLoss = (r.avgLoss*(n-1) + loss) / n
+	}
+
+	if !r.Ready() {
+		return nan()
+	}
+
+	switch {
+	case r.avgLoss == 0 && r.avgGain == 0:
+		return 50
+	case r.avgLoss == 0:
+		return 100
+	}
+
+	return 100 - 100/(1+r.avgGain/r.avgLoss)
+}
+
+func (r *RSI) Ready() bool {
+	return r.count > r.period
+}
+
+// MACDValue - MACD line, its signal line and their difference
+type MACDValue struct {
+	MACD      float64
+	Signal    float64
+	Histogram float64
+}
+
+/*
+MACD - Moving average convergence divergence, the difference of a fast and a
+slow EMA with an EMA of the difference as signal line
+*/
+type MACD struct {
+	fast   *EMA
+	slow   *EMA
+	signal *EMA
+}
+
+// NewMACD - returns a MACD, commonly NewMACD(12, 26, 9)
+func NewMACD(fast, slow, signal int) *MACD {
+	return &MACD{
+		fast:   NewEMA(fast),
+		slow:   NewEMA(slow),
+		signal: NewEMA(signal),
+	}
+}
+
+// Update - adds a value and returns the MACD, fields are NaN until ready
+func (m *MACD) Update(v float64) MACDValue {
+	fast, slow := m.fast.Update(v), m.slow.Update(v)
+	if !m.fast.Ready() || !m.slow.Ready() {
+		return MACDValue{MACD: nan(), Signal: nan(), Histogram: nan()}
+	}
+
+	macd := fast - slow
+	signal := m.signal.Update(macd)
+	return MACDValue{MACD: macd, Signal: signal, Histogram: macd - signal}
+}
+
+// Ready - the signal line is available
+func (m *MACD) Ready() bool {
+	return m.signal.Ready()
+}
+
+// MACDSeries - runs a new MACD over values
+func MACDSeries(values []float64, fast, slow, signal int) []MACDValue {
+	m := NewMACD(fast, slow, signal)
+
+	out := make([]MACDValue, len(values))
+	for i, v := range values {
+		out[i] = m.Update(v)
+	}
+
+	return out
+}
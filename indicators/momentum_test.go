@@ -0,0 +1,53 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRSIUnit(t *testing.T) {
+	// StockCharts 14 day RSI
+	closes := []float64{
+		44.3389, 44.0902, 44.1497, 43.6124, 44.3278, 44.8264, 45.0955, 45.4245, 45.8433, 46.0826,
+		45.8931, 46.0328, 45.6140, 46.2820, 46.2820, 46.0028, 46.0328, 46.4116, 46.2222, 45.6439,
+		46.2122, 46.2521, 45.7137, 46.4515, 45.7835, 45.3548, 44.0288, 44.1783, 44.2181, 44.5672,
+		43.4205, 42.6628, 43.1314,
+	}
+	assertSeries(t, []float64{
+		70.53, 66.32, 66.55, 69.41, 66.36, 57.97, 62.93, 63.26, 56.06, 62.38,
+		54.71, 50.42, 39.99, 41.46, 41.87, 45.46, 37.30, 33.08, 37.77,
+	}, Batch(NewRSI(14), closes), 0.005)
+
+	assertSeries(t, []float64{100, 100}, Batch(NewRSI(2), []float64{1, 2, 3, 4}), 0)
+	assertSeries(t, []float64{50}, Batch(NewRSI(2), []float64{1, 1, 1}), 0)
+}
+
+func TestMACDUnit(t *testing.T) {
+	values := MACDSeries(emaPrices, 3, 6, 4)
+
+	for _, v := range values[:8] {
+		assert.True(t, math.IsNaN(v.Signal))
+	}
+	assert.True(t, math.IsNaN(values[4].MACD))
+	assert.False(t, math.IsNaN(values[5].MACD))
+
+	for i, want := range map[int]MACDValue{
+		8:  {MACD: 0.023661, Signal: 0.016638, Histogram: 0.007024},
+		15: {MACD: 0.420329, Signal: 0.244717, Histogram: 0.175613},
+		29: {MACD: -0.278298, Signal: -0.20711, Histogram: -0.071188},
+	} {
+		assert.InDelta(t, want.MACD, values[i].MACD, 1e-6, "macd %d", i)
+		assert.InDelta(t, want.Signal, values[i].Signal, 1e-6, "signal %d", i)
+		assert.InDelta(t, want.Histogram, values[i].Histogram, 1e-6, "histogram %d", i)
+	}
+
+	m := NewMACD(3, 6, 4)
+	for _, v := range emaPrices[:8] {
+		m.Update(v)
+	}
+	assert.False(t, m.Ready())
+	m.Update(emaPrices[8])
+	assert.True(t, m.Ready())
+}
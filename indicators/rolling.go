@@ -0,0 +1,53 @@
+package indicators
+
+// rollingEntry - value and position of a rolling window candidate
+type rollingEntry struct {
+	index int
+	value float64
+}
+
+/*
+Rolling - Highest or lowest of the latest period values, kept in a monotonic
+queue so each update is amortized constant time
+*/
+type Rolling struct {
+	period int
+	count  int
+	keep   func(candidate, v float64) bool
+	queue  []rollingEntry
+}
+
+// NewRollingHigh - returns the highest of the latest period values
+func NewRollingHigh(period int) *Rolling {
+	checkPeriod(period)
+	return &Rolling{period: period, keep: func(candidate, v float64) bool { return candidate > v }}
+}
+
+// NewRollingLow - returns the lowest of the latest period values
+func NewRollingLow(period int) *Rolling {
+	checkPeriod(period)
+	return &Rolling{period: period, keep: func(candidate, v float64) bool { return candidate < v }}
+}
+
+func (r *Rolling) Update(v float64) float64 {
+	// drop candidates that can no longer be the extreme
+	for len(r.queue) > 0 && !r.keep(r.queue[len(r.queue)-1].value, v) {
+		r.queue = r.queue[:len(r.queue)-1]
+	}
+	r.queue = append(r.queue, rollingEntry{index: r.count, value: v})
+	r.count++
+
+	if r.queue[0].index <= r.count-1-r.period {
+		r.queue = r.queue[1:]
+	}
+
+	if !r.Ready() {
+		return nan()
+	}
+
+	return r.queue[0].value
+}
+
+func (r *Rolling) Ready() bool {
+	return r.count >= r.period
+}
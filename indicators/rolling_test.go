@@ -0,0 +1,13 @@
+package indicators
+
+import (
+	"testing"
+)
+
+func TestRollingUnit(t *testing.T) {
+	values := []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3}
+
+	assertSeries(t, []float64{4, 4, 5, 9, 9, 9, 6, 6}, Batch(NewRollingHigh(3), values), 0)
+	assertSeries(t, []float64{1, 1, 1, 1, 2, 2, 2, 3}, Batch(NewRollingLow(3), values), 0)
+	assertSeries(t, []float64{3, 1, 4, 1, 5, 9, 2, 6, 5, 3}, Batch(NewRollingHigh(1), values), 0)
+}
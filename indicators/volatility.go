@@ -0,0 +1,117 @@
+package indicators
+
+import (
+	"math"
+
+	"github.com/fincodetoad/ibweb"
+)
+
+/*
+ATR - Average true range using Wilder's smoothing, seeded with the simple average
+of the first period true ranges. The first bar's true range is its high less low.
+*/
+type ATR struct {
+	period int
+	count  int
+	prev   float64
+	value  float64
+}
+
+// NewATR - returns an average true range over period bars
+func NewATR(period int) *ATR {
+	checkPeriod(period)
+	return &ATR{period: period}
+}
+
+// Update - adds a bar and returns the average true range, NaN until ready
+func (a *ATR) Update(bar ibweb.Bar) float64 {
+	tr := bar.High - bar.Low
+	if a.count > 0 {
+		tr = math.Max(tr, math.Max(math.Abs(bar.High-a.prev), math.Abs(bar.Low-a.prev)))
+	}
+	a.prev = bar.Close
+
+	n := float64(a.period)
+	if a.count < a.period {
+		a.count++
+		a.value += tr / n
+		if !a.Ready() {
+			return nan()
+		}
+
+		return a.value
+	}
+
+	a.value = (a.value*(n-1) + tr) / n
+	return a.value
+}
+
+func (a *ATR) Ready() bool {
+	return a.count == a.period
+}
+
+// ATRSeries - runs a new ATR over bars
+func ATRSeries(bars []ibweb.Bar, period int) []float64 {
+	a := NewATR(period)
+
+	out := make([]float64, len(bars))
+	for i, bar := range bars {
+		out[i] = a.Update(bar)
+	}
+
+	return out
+}
+
+// Band - Bollinger bands around a simple moving average
+type Band struct {
+	Upper  float64
+	Middle float64
+	Lower  float64
+}
+
+// Bollinger - Bollinger bands k population standard deviations around an SMA
+type Bollinger struct {
+	k      float64
+	sma    *SMA
+	window *window
+}
+
+// NewBollinger - returns Bollinger bands over period values, commonly NewBollinger(20, 2)
+func NewBollinger(period int, k float64) *Bollinger {
+	checkPeriod(period)
+	return &Bollinger{k: k, sma: NewSMA(period), window: newWindow(period)}
+}
+
+// Update - adds a value and returns the bands, fields are NaN until ready
+func (b *Bollinger) Update(v float64) Band {
+	b.window.push(v)
+	middle := b.sma.Update(v)
+	if !b.Ready() {
+		return Band{Upper: nan(), Middle: nan(), Lower: nan()}
+	}
+
+	var variance float64
+	for i := 0; i < b.sma.period; i++ {
+		d := b.window.at(i) - middle
+		variance += d * d
+	}
+	width := b.k * math.Sqrt(variance/float64(b.sma.period))
+
+	return Band{Upper: middle + width, Middle: middle, Lower: middle - width}
+}
+
+func (b *Bollinger) Ready() bool {
+	return b.sma.Ready()
+}
+
+// BollingerSeries - runs new Bollinger bands over values
+func BollingerSeries(values []float64, period int, k float64) []Band {
+	b := NewBollinger(period, k)
+
+	out := make([]Band, len(values))
+	for i, v := range values {
+		out[i] = b.Update(v)
+	}
+
+	return out
+}
@@ -0,0 +1,42 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+
+	"github.com/fincodetoad/ibweb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestATRUnit(t *testing.T) {
+	// StockCharts 14 day ATR
+	var bars []ibweb.Bar
+	for _, hlc := range [][3]float64{
+		{48.70, 47.79, 48.16}, {48.72, 48.14, 48.61}, {48.90, 48.39, 48.75}, {48.87, 48.37, 48.63},
+		{48.82, 48.24, 48.74}, {49.05, 48.64, 49.03}, {49.20, 48.94, 49.07}, {49.35, 48.86, 49.32},
+		{49.92, 49.50, 49.91}, {50.19, 49.87, 50.13}, {50.12, 49.20, 49.53}, {49.66, 48.90, 49.50},
+		{49.88, 49.43, 49.75}, {50.19, 49.73, 50.03}, {50.36, 49.26, 50.31}, {50.57, 50.09, 50.52},
+		{50.65, 50.30, 50.41},
+	} {
+		bars = append(bars, ibweb.Bar{High: hlc[0], Low: hlc[1], Close: hlc[2]})
+	}
+
+	assertSeries(t, []float64{0.554286, 0.593265, 0.585175, 0.568377}, ATRSeries(bars, 14), 1e-6)
+}
+
+func TestBollingerUnit(t *testing.T) {
+	bands := BollingerSeries(emaPrices, 20, 2)
+
+	for _, band := range bands[:19] {
+		assert.True(t, math.IsNaN(band.Middle))
+	}
+
+	for i, want := range map[int]Band{
+		19: {Upper: 24.1261, Middle: 22.7155, Lower: 21.3049},
+		29: {Upper: 24.4355, Middle: 23.1705, Lower: 21.9055},
+	} {
+		assert.InDelta(t, want.Upper, bands[i].Upper, 1e-4, "upper %d", i)
+		assert.InDelta(t, want.Middle, bands[i].Middle, 1e-4, "middle %d", i)
+		assert.InDelta(t, want.Lower, bands[i].Lower, 1e-4, "lower %d", i)
+	}
+}
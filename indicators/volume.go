@@ -0,0 +1,70 @@
+package indicators
+
+import (
+	"time"
+
+	"github.com/fincodetoad/ibweb"
+)
+
+/*
+VWAP - Volume weighted average price accumulated over a day, restarting with the
+first bar of each calendar day in the zone of the bar times. Bars trade at their
+VWAP when set, as on resampled bars, otherwise at their typical price.
+*/
+type VWAP struct {
+	day      time.Time
+	volume   float64
+	notional float64
+}
+
+// NewVWAP - returns a VWAP
+func NewVWAP() *VWAP {
+	return &VWAP{}
+}
+
+// Update - adds a bar and returns the VWAP of the day, NaN until volume has traded
+func (v *VWAP) Update(bar ibweb.Bar) float64 {
+	y, m, d := bar.Time.Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, bar.Time.Location())
+	if !day.Equal(v.day) {
+		v.Reset()
+		v.day = day
+	}
+
+	price := bar.VWAP
+	if price == 0 {
+		price = (bar.High + bar.Low + bar.Close) / 3
+	}
+	v.volume += bar.Volume
+	v.notional += price * bar.Volume
+
+	if !v.Ready() {
+		return nan()
+	}
+
+	return v.notional / v.volume
+}
+
+// Ready - volume has traded since the start of the day
+func (v *VWAP) Ready() bool {
+	return v.volume > 0
+}
+
+// Reset - restarts the accumulation, e.g. at the open of a session
+func (v *VWAP) Reset() {
+	v.day = time.Time{}
+	v.volume = 0
+	v.notional = 0
+}
+
+// VWAPSeries - runs a new VWAP over bars
+func VWAPSeries(bars []ibweb.Bar) []float64 {
+	v := NewVWAP()
+
+	out := make([]float64, len(bars))
+	for i, bar := range bars {
+		out[i] = v.Update(bar)
+	}
+
+	return out
+}
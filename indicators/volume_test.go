@@ -0,0 +1,34 @@
+package indicators
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/fincodetoad/ibweb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVWAPUnit(t *testing.T) {
+	day := time.Date(2023, 11, 27, 9, 30, 0, 0, time.UTC)
+	bars := []ibweb.Bar{
+		{Time: day, High: 11, Low: 9, Close: 10, Volume: 0},
+		{Time: day.Add(time.Minute), High: 12, Low: 10, Close: 11, Volume: 100},
+		{Time: day.Add(2 * time.Minute), High: 13, Low: 11, Close: 12, Volume: 300},
+		{Time: day.Add(3 * time.Minute), High: 14, Low: 12, Close: 13, Volume: 100, VWAP: 12.5},
+		// the next day restarts the accumulation
+		{Time: day.AddDate(0, 0, 1), High: 21, Low: 19, Close: 20, Volume: 50},
+	}
+
+	values := VWAPSeries(bars)
+	assert.True(t, math.IsNaN(values[0]))
+	assert.InDelta(t, 11.0, values[1], 1e-9)
+	assert.InDelta(t, 11.75, values[2], 1e-9)
+	assert.InDelta(t, (11*100+12*300+12.5*100)/500.0, values[3], 1e-9)
+	assert.InDelta(t, 20.0, values[4], 1e-9)
+
+	v := NewVWAP()
+	v.Update(bars[1])
+	v.Reset()
+	assert.False(t, v.Ready())
+}
@@ -3,41 +3,229 @@ package ibweb
 import (
 	"encoding/json"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	marketDataHistory  = "v1/api/iserver/marketdata/history"
+	marketDataSnapshot = "v1/api/iserver/marketdata/snapshot"
+)
+
+var (
+	// snapshotAttempts - number of snapshot requests made while waiting for fields to be primed
+	snapshotAttempts = 5
+	// snapshotRetryInterval - wait between snapshot requests while fields are primed
+	snapshotRetryInterval = 500 * time.Millisecond
 )
 
+// SnapshotField - Market data field code
+type SnapshotField int
+
 const (
-	marketDataHistory = "v1/api/iserver/marketdata/history"
+	FieldLast                   SnapshotField = 31
+	FieldSymbol                 SnapshotField = 55
+	FieldHigh                   SnapshotField = 70
+	FieldLow                    SnapshotField = 71
+	FieldMarketValue            SnapshotField = 73
+	FieldAvgPrice               SnapshotField = 74
+	FieldUnrealizedPnl          SnapshotField = 75
+	FieldChange                 SnapshotField = 82
+	FieldChangePercent          SnapshotField = 83
+	FieldBid                    SnapshotField = 84
+	FieldAskSize                SnapshotField = 85
+	FieldAsk                    SnapshotField = 86
+	FieldVolume                 SnapshotField = 87
+	FieldBidSize                SnapshotField = 88
+	FieldExchange               SnapshotField = 6004
+	FieldConid                  SnapshotField = 6008
+	FieldSecType                SnapshotField = 6070
+	FieldMarketDataAvailability SnapshotField = 6509
+	FieldLastSize               SnapshotField = 7059
+	FieldOpen                   SnapshotField = 7295
+	FieldClose                  SnapshotField = 7296
+	FieldDelta                  SnapshotField = 7308
+	FieldGamma                  SnapshotField = 7309
+	FieldTheta                  SnapshotField = 7310
+	FieldVega                   SnapshotField = 7311
+	FieldImpliedVolatility      SnapshotField = 7633
+	FieldMark                   SnapshotField = 7635
+	FieldPriorClose             SnapshotField = 7741
+	FieldVolumeLong             SnapshotField = 7762
 )
 
+func (s SnapshotField) String() string {
+	return strconv.Itoa(int(s))
+}
+
 type MarketDataHistory struct {
-	ServerID           string `json:"serverId"`
-	Symbol             string `json:"symbol"`
-	Text               string `json:"text"`
-	PriceFactor        int    `json:"priceFactor"`
-	StartTime          string `json:"startTime"`
-	High               string `json:"high"`
-	Low                string `json:"low"`
-	TimePeriod         string `json:"timePeriod"`
-	BarLength          int    `json:"barLength"`
-	MdAvailability     string `json:"mdAvailability"`
-	MktDataDelay       int    `json:"mktDataDelay"`
-	OutsideRth         bool   `json:"outsideRth"`
-	TradingDayDuration int    `json:"tradingDayDuration"`
-	VolumeFactor       int    `json:"volumeFactor"`
-	PriceDisplayRule   int    `json:"priceDisplayRule"`
-	PriceDisplayValue  string `json:"priceDisplayValue"`
-	NegativeCapable    bool   `json:"negativeCapable"`
-	MessageVersion     int    `json:"messageVersion"`
-	Data               []struct {
-		O float64 `json:"o"`
-		C float64 `json:"c"`
-		H float64 `json:"h"`
-		L float64 `json:"l"`
-		V int     `json:"v"`
-		T int64   `json:"t"`
-	} `json:"data"`
-	Points     int `json:"points"`
-	TravelTime int `json:"travelTime"`
+	ServerID           string       `json:"serverId"`
+	Symbol             string       `json:"symbol"`
+	Text               string       `json:"text"`
+	PriceFactor        int          `json:"priceFactor"`
+	StartTime          string       `json:"startTime"`
+	High               string       `json:"high"`
+	Low                string       `json:"low"`
+	TimePeriod         string       `json:"timePeriod"`
+	BarLength          int          `json:"barLength"`
+	MdAvailability     string       `json:"mdAvailability"`
+	MktDataDelay       int          `json:"mktDataDelay"`
+	OutsideRth         bool         `json:"outsideRth"`
+	TradingDayDuration int          `json:"tradingDayDuration"`
+	VolumeFactor       int          `json:"volumeFactor"`
+	PriceDisplayRule   int          `json:"priceDisplayRule"`
+	PriceDisplayValue  string       `json:"priceDisplayValue"`
+	NegativeCapable    bool         `json:"negativeCapable"`
+	MessageVersion     int          `json:"messageVersion"`
+	Data               []HistoryBar `json:"data"`
+	Points             int          `json:"points"`
+	TravelTime         int          `json:"travelTime"`
+}
+
+// HistoryBar - Bar as sent by the gateway, T is in epoch milliseconds
+type HistoryBar struct {
+	O float64 `json:"o"`
+	C float64 `json:"c"`
+	H float64 `json:"h"`
+	L float64 `json:"l"`
+	V float64 `json:"v"`
+	T int64   `json:"t"`
+}
+
+// Bar - Typed historical bar with scaled volume
+type Bar struct {
+	Time   time.Time `json:"time"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Volume float64   `json:"volume"`
+	// VWAP - volume weighted average price, only set on resampled bars
+	VWAP float64 `json:"vwap,omitempty"`
+}
+
+// HistoryExtreme - High or low of a history request
+type HistoryExtreme struct {
+	Price  float64
+	Volume float64
+	// Bar - index of the bar the extreme was reached in
+	Bar int
+}
+
+const historyStartTimeLayout = "20060102-15:04:05"
+
+/*
+Bars - Gets the bars ordered by time with duplicates removed. Timestamps are in
+loc, e.g. the exchange zone from TradingSchedule.Location, nil uses UTC. Bar
+prices are sent as display prices, unlike High and Low which are multiplied by
+PriceFactor, and volumes are scaled by VolumeFactor.
+*/
+func (m MarketDataHistory) Bars(loc *time.Location) []Bar {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	volumeFactor := float64(m.VolumeFactor)
+	if volumeFactor == 0 {
+		volumeFactor = 1
+	}
+
+	bars := make([]Bar, 0, len(m.Data))
+	for _, d := range m.Data {
+		bars = append(bars, Bar{
+			Time:   time.UnixMilli(d.T).In(loc),
+			Open:   d.O,
+			High:   d.H,
+			Low:    d.L,
+			Close:  d.C,
+			Volume: d.V * volumeFactor,
+		})
+	}
+
+	return cleanBars(bars)
+}
+
+// cleanBars - sorts bars by time keeping the last of bars sharing a timestamp
+func cleanBars(bars []Bar) []Bar {
+	sort.SliceStable(bars, func(i, j int) bool { return bars[i].Time.Before(bars[j].Time) })
+
+	clean := bars[:0]
+	for _, bar := range bars {
+		if n := len(clean); n > 0 && clean[n-1].Time.Equal(bar.Time) {
+			clean[n-1] = bar
+			continue
+		}
+		clean = append(clean, bar)
+	}
+
+	return clean
+}
+
+// Start - gets the parsed StartTime in loc, nil uses UTC
+func (m MarketDataHistory) Start(loc *time.Location) (time.Time, error) {
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	t, err := time.ParseInLocation(historyStartTimeLayout, m.StartTime, time.UTC)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid start time '%s'", m.StartTime)
+	}
+
+	return t.In(loc), nil
+}
+
+// Highest - gets the parsed High, its price is sent multiplied by PriceFactor
+func (m MarketDataHistory) Highest() (HistoryExtreme, error) {
+	return m.parseExtreme(m.High)
+}
+
+// Lowest - gets the parsed Low, its price is sent multiplied by PriceFactor
+func (m MarketDataHistory) Lowest() (HistoryExtreme, error) {
+	return m.parseExtreme(m.Low)
+}
+
+// parseExtreme - parses an extreme sent as price/volume/bar, e.g. 13980/3/4
+func (m MarketDataHistory) parseExtreme(s string) (HistoryExtreme, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 3 {
+		return HistoryExtreme{}, errors.Errorf("invalid extreme '%s'", s)
+	}
+
+	price, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil {
+		return HistoryExtreme{}, errors.Wrapf(err, "invalid extreme price '%s'", s)
+	}
+
+	volume, err := strconv.ParseFloat(parts[1], 64)
+	if err != nil {
+		return HistoryExtreme{}, errors.Wrapf(err, "invalid extreme volume '%s'", s)
+	}
+
+	bar, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return HistoryExtreme{}, errors.Wrapf(err, "invalid extreme bar '%s'", s)
+	}
+
+	priceFactor := float64(m.PriceFactor)
+	if priceFactor == 0 {
+		priceFactor = 1
+	}
+
+	volumeFactor := float64(m.VolumeFactor)
+	if volumeFactor == 0 {
+		volumeFactor = 1
+	}
+
+	return HistoryExtreme{
+		Price:  price / priceFactor,
+		Volume: volume * volumeFactor,
+		Bar:    bar,
+	}, nil
 }
 
 type MarketDataHistoryInput struct {
@@ -46,6 +234,8 @@ type MarketDataHistoryInput struct {
 	Period     string
 	Bar        string
 	OutsideRth bool
+	// StartTime - end of the requested period, the gateway walks back Period from it
+	StartTime time.Time
 }
 
 func (m MarketDataHistoryInput) toQuery() []query {
@@ -84,6 +274,13 @@ func (m MarketDataHistoryInput) toQuery() []query {
 		})
 	}
 
+	if !m.StartTime.IsZero() {
+		queries = append(queries, query{
+			key:   "startTime",
+			value: m.StartTime.UTC().Format(historyStartTimeLayout),
+		})
+	}
+
 	return queries
 }
 
@@ -112,5 +309,263 @@ func (c *client) MarketDataHistory(input MarketDataHistoryInput) (*MarketDataHis
 		return nil, err
 	}
 
+	conid, _ := strconv.Atoi(input.ConID)
+	if err := c.delayedDataPolicy.check(OperationMarketDataHistory, conid, marketDataHistory.Availability()); err != nil {
+		return nil, err
+	}
+
 	return &marketDataHistory, nil
 }
+
+/*
+MarketDataSnapshotInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1iserver~1marketdata~1snapshot/get
+*/
+type MarketDataSnapshotInput struct {
+	Conids []int
+	Fields []SnapshotField
+}
+
+func (m MarketDataSnapshotInput) toQuery() []query {
+	conids := make([]string, 0, len(m.Conids))
+	for _, conid := range m.Conids {
+		conids = append(conids, strconv.Itoa(conid))
+	}
+
+	queries := []query{
+		{
+			key:   "conids",
+			value: strings.Join(conids, ","),
+		},
+	}
+
+	if len(m.Fields) > 0 {
+		fields := make([]string, 0, len(m.Fields))
+		for _, field := range m.Fields {
+			fields = append(fields, field.String())
+		}
+
+		queries = append(queries, query{
+			key:   "fields",
+			value: strings.Join(fields, ","),
+		})
+	}
+
+	return queries
+}
+
+// SnapshotValue - Decoded value of a snapshot field
+type SnapshotValue struct {
+	// Raw - value as sent by the gateway
+	Raw string
+	// Value - numeric value, zero when the field is not numeric
+	Value float64
+	// Available - the field was returned
+	Available bool
+	// Numeric - Raw could be parsed into Value
+	Numeric bool
+	// Closing - the price is the prior close, sent with a C prefix
+	Closing bool
+	// Halted - trading is halted, sent with an H prefix
+	Halted bool
+}
+
+/*
+Snapshot - Market data snapshot of a contract
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1iserver~1marketdata~1snapshot/get
+*/
+type Snapshot struct {
+	Conid    int
+	ConidEx  string
+	ServerID string
+	Updated  time.Time
+	Fields   map[SnapshotField]SnapshotValue
+}
+
+// Get - gets the value of a field, unavailable when not returned
+func (s Snapshot) Get(field SnapshotField) SnapshotValue {
+	return s.Fields[field]
+}
+
+// Has - reports if every field was returned
+func (s Snapshot) Has(fields ...SnapshotField) bool {
+	for _, field := range fields {
+		if !s.Fields[field].Available {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s *Snapshot) UnmarshalJSON(v []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(v, &raw); err != nil {
+		return err
+	}
+
+	snapshot := Snapshot{Fields: map[SnapshotField]SnapshotValue{}}
+	for key, value := range raw {
+		switch key {
+		case "conid":
+			if err := json.Unmarshal(value, &snapshot.Conid); err != nil {
+				return errors.Wrap(err, "failed to unmarshal snapshot conid")
+			}
+		case "conidEx":
+			if err := json.Unmarshal(value, &snapshot.ConidEx); err != nil {
+				return errors.Wrap(err, "failed to unmarshal snapshot conidEx")
+			}
+		case "server_id":
+			if err := json.Unmarshal(value, &snapshot.ServerID); err != nil {
+				return errors.Wrap(err, "failed to unmarshal snapshot server_id")
+			}
+		case "_updated":
+			var updated int64
+			if err := json.Unmarshal(value, &updated); err != nil {
+				return errors.Wrap(err, "failed to unmarshal snapshot _updated")
+			}
+			snapshot.Updated = time.UnixMilli(updated)
+		default:
+			code, err := strconv.Atoi(key)
+			if err != nil {
+				continue
+			}
+
+			snapshot.Fields[SnapshotField(code)] = decodeSnapshotValue(value)
+		}
+	}
+
+	*s = snapshot
+	return nil
+}
+
+func decodeSnapshotValue(v json.RawMessage) SnapshotValue {
+	raw := strings.Trim(string(v), `"`)
+	value := SnapshotValue{Raw: raw, Available: true}
+
+	s := strings.ReplaceAll(strings.TrimSpace(raw), ",", "")
+	if strings.HasPrefix(s, "C") {
+		value.Closing = true
+		s = s[1:]
+	} else if strings.HasPrefix(s, "H") {
+		value.Halted = true
+		s = s[1:]
+	}
+
+	multiplier := 1.0
+	switch {
+	case strings.HasSuffix(s, "%"):
+		s = strings.TrimSuffix(s, "%")
+	case strings.HasSuffix(s, "K"):
+		multiplier, s = 1e3, strings.TrimSuffix(s, "K")
+	case strings.HasSuffix(s, "M"):
+		multiplier, s = 1e6, strings.TrimSuffix(s, "M")
+	case strings.HasSuffix(s, "B"):
+		multiplier, s = 1e9, strings.TrimSuffix(s, "B")
+	}
+
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		// not numeric, prefixes only apply to prices
+		value.Closing, value.Halted = false, false
+		return value
+	}
+
+	value.Value = f * multiplier
+	value.Numeric = true
+	return value
+}
+
+/*
+MarketDataSnapshot - Gets a market data snapshot of contracts. The first request
+for a contract only primes the gateway and may return no fields, see Snapshot.
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1iserver~1marketdata~1snapshot/get
+*/
+func (c *client) MarketDataSnapshot(input MarketDataSnapshotInput) ([]Snapshot, error) {
+	if c.delayedDataPolicy.appliesTo(OperationMarketDataSnapshot) {
+		input.Fields = withSnapshotField(input.Fields, FieldMarketDataAvailability)
+	}
+
+	resp, err := c.get(marketDataSnapshot, input.toQuery()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []Snapshot
+	if err := json.Unmarshal(v, &snapshots); err != nil {
+		return nil, err
+	}
+
+	for _, snapshot := range snapshots {
+		if err := c.delayedDataPolicy.check(OperationMarketDataSnapshot, snapshot.Conid, snapshot.Availability()); err != nil {
+			return nil, err
+		}
+	}
+
+	return snapshots, nil
+}
+
+/*
+Snapshot - Gets a market data snapshot of contracts, repeating the request until
+every field is returned for every contract or snapshotAttempts is reached. The
+last snapshots are returned, check SnapshotValue.Available for missing fields.
+*/
+func (c *client) Snapshot(conids []int, fields []SnapshotField) ([]Snapshot, error) {
+	input := MarketDataSnapshotInput{Conids: conids, Fields: fields}
+
+	var snapshots []Snapshot
+	for attempt := 0; attempt < snapshotAttempts; attempt++ {
+		if attempt > 0 {
+			sleepFn(snapshotRetryInterval)
+		}
+
+		var err error
+		snapshots, err = c.MarketDataSnapshot(input)
+		if err != nil {
+			return nil, err
+		}
+
+		if snapshotsComplete(snapshots, conids, fields) {
+			break
+		}
+	}
+
+	return snapshots, nil
+}
+
+// withSnapshotField - gets fields with field appended when missing, fields is not modified
+func withSnapshotField(fields []SnapshotField, field SnapshotField) []SnapshotField {
+	for _, f := range fields {
+		if f == field {
+			return fields
+		}
+	}
+
+	return append(append(make([]SnapshotField, 0, len(fields)+1), fields...), field)
+}
+
+func snapshotsComplete(snapshots []Snapshot, conids []int, fields []SnapshotField) bool {
+	byConid := map[int]Snapshot{}
+	for _, snapshot := range snapshots {
+		byConid[snapshot.Conid] = snapshot
+	}
+
+	for _, conid := range conids {
+		snapshot, ok := byConid[conid]
+		if !ok || !snapshot.Has(fields...) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -2,13 +2,16 @@ package ibweb
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -158,3 +161,235 @@ func TestMarketDataHistoryUnit(t *testing.T) {
 		httpmock.DeactivateAndReset()
 	}
 }
+
+func TestMarketDataHistoryBarsUnit(t *testing.T) {
+	v, err := os.ReadFile("testdata/market_data_history.json")
+	assert.Nil(t, err)
+
+	var history MarketDataHistory
+	assert.Nil(t, json.Unmarshal(v, &history))
+
+	loc, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	// duplicated and out of order bars are cleaned up
+	history.Data = append(history.Data, history.Data[0])
+	history.Data[0], history.Data[1] = history.Data[1], history.Data[0]
+	history.VolumeFactor = 100
+
+	bars := history.Bars(loc)
+	assert.Len(t, bars, 5)
+	assert.Equal(t, time.Date(2023, 11, 27, 15, 55, 0, 0, loc), bars[0].Time)
+	assert.Equal(t, loc, bars[0].Time.Location())
+	assert.Equal(t, 139.75, bars[0].Open)
+	assert.Equal(t, 200.0, bars[0].Volume)
+	assert.Equal(t, 139.8, bars[4].Close)
+	for i := 1; i < len(bars); i++ {
+		assert.True(t, bars[i-1].Time.Before(bars[i].Time))
+	}
+
+	start, err := history.Start(loc)
+	assert.Nil(t, err)
+	assert.True(t, start.Equal(bars[0].Time))
+
+	high, err := history.Highest()
+	assert.Nil(t, err)
+	assert.Equal(t, HistoryExtreme{Price: 139.8, Volume: 300, Bar: 4}, high)
+
+	low, err := history.Lowest()
+	assert.Nil(t, err)
+	assert.Equal(t, HistoryExtreme{Price: 139.75, Volume: 200, Bar: 0}, low)
+
+	history.High = "13980/3"
+	_, err = history.Highest()
+	assert.NotNil(t, err)
+
+	history.StartTime = "2023-11-27"
+	_, err = history.Start(nil)
+	assert.NotNil(t, err)
+}
+
+func TestMarketDataSnapshotIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	snapshots, err := c.Snapshot([]int{265598}, []SnapshotField{FieldLast, FieldBid, FieldAsk})
+	assert.Nil(t, err)
+	assert.Greater(t, len(snapshots), 0)
+}
+
+func TestMarketDataSnapshotUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get market data snapshot")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get market data snapshot",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read market data snapshot")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read market data snapshot",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/market_data_snapshot.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataSnapshot), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.MarketDataSnapshot(MarketDataSnapshotInput{Conids: []int{265598}})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestSnapshotDecodeUnit(t *testing.T) {
+	v, err := os.ReadFile("./testdata/market_data_snapshot.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var snapshots []Snapshot
+	if !assert.Nil(t, json.Unmarshal(v, &snapshots)) {
+		t.FailNow()
+	}
+
+	aapl := snapshots[0]
+	assert.Equal(t, 265598, aapl.Conid)
+	assert.Equal(t, "q0", aapl.ServerID)
+	assert.True(t, time.UnixMilli(1701118500000).Equal(aapl.Updated))
+
+	assert.Equal(t, SnapshotValue{Raw: "C189.79", Value: 189.79, Available: true, Numeric: true, Closing: true}, aapl.Get(FieldLast))
+	assert.Equal(t, 189.75, aapl.Get(FieldBid).Value)
+	assert.Equal(t, 1200.0, aapl.Get(FieldAskSize).Value)
+	assert.Equal(t, 48.2e6, aapl.Get(FieldVolume).Value)
+	assert.Equal(t, -0.52, aapl.Get(FieldChangePercent).Value)
+	assert.Equal(t, 48213576.0, aapl.Get(FieldVolumeLong).Value)
+	assert.Equal(t, SnapshotValue{Raw: "AAPL", Available: true}, aapl.Get(FieldSymbol))
+	assert.False(t, aapl.Get(FieldDelta).Available)
+	assert.True(t, aapl.Has(FieldLast, FieldBid, FieldAsk))
+	assert.False(t, aapl.Has(FieldDelta))
+
+	option := snapshots[1]
+	assert.True(t, option.Get(FieldLast).Halted)
+	assert.Equal(t, 0.312, option.Get(FieldDelta).Value)
+}
+
+func TestSnapshotPrimingUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	var slept []time.Duration
+	sleepFn = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleepFn = time.Sleep }()
+
+	calls := 0
+	var lastQuery url.Values
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataSnapshot),
+		func(req *http.Request) (*http.Response, error) {
+			lastQuery = req.URL.Query()
+
+			calls++
+			if calls == 1 {
+				return httpmock.NewStringResponse(200, `[{"conid":265598},{"conid":659248794}]`), nil
+			}
+
+			return httpmock.NewStringResponse(200, httpmock.File("./testdata/market_data_snapshot.json").String()), nil
+		})
+
+	c := New("http://127.0.0.1:5555")
+	snapshots, err := c.Snapshot([]int{265598, 659248794}, []SnapshotField{FieldLast, FieldBid, FieldAsk})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, "265598,659248794", lastQuery.Get("conids"))
+	assert.Equal(t, "31,84,86", lastQuery.Get("fields"))
+	assert.Equal(t, []time.Duration{snapshotRetryInterval}, slept)
+	assert.True(t, snapshots[1].Has(FieldLast, FieldBid, FieldAsk))
+
+	// gives up once the attempts are used and returns what is available
+	calls = 0
+	snapshots, err = c.Snapshot([]int{265598}, []SnapshotField{FieldDelta})
+	assert.Nil(t, err)
+	assert.Equal(t, snapshotAttempts, calls)
+	assert.False(t, snapshots[0].Has(FieldDelta))
+}
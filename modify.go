@@ -0,0 +1,184 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// orderStatusTypes - order types as reported by OrderStatus
+var orderStatusTypes = map[string]OrderType{
+	"LIMIT":       Limit,
+	"LMT":         Limit,
+	"MARKET":      Market,
+	"MKT":         Market,
+	"STOP":        Stop,
+	"STP":         Stop,
+	"STOP_LIMIT":  StopLimit,
+	"STOP LIMIT":  StopLimit,
+	"MIDPRICE":    MidPrice,
+	"TRAIL":       Trail,
+	"TRAILING":    Trail,
+	"TRAILLMT":    TrailLimit,
+	"TRAIL_LIMIT": TrailLimit,
+}
+
+/*
+OrderModification - Changes to a working order, nil and empty fields keep their
+current value. The trailing amount and type are not reported by OrderStatus, so
+both are required to modify trailing orders.
+*/
+type OrderModification struct {
+	Price *float64
+	// AuxPrice - stop price, sent as the price of STP orders and as the auxPrice otherwise
+	AuxPrice   *float64
+	Quantity   *float64
+	Tif        TimeInForce
+	OutsideRTH *bool
+	// TrailingAmt - trailing amount of TRAIL and TRAILLMT orders
+	TrailingAmt *float64
+	// TrailingType - trailing type of TRAIL and TRAILLMT orders, amt or %
+	TrailingType string
+}
+
+/*
+Modify - Builds the order to pass to ModifyOrder from the current status and the
+modification. The gateway expects the full order, not only the changed fields.
+*/
+func (o OrderStatus) Modify(m OrderModification) (Order, error) {
+	if o.OrderNotEditable {
+		return Order{}, errors.Errorf("order '%d' is not editable", o.OrderID)
+	}
+
+	orderType, ok := orderStatusTypes[strings.ToUpper(o.OrderType)]
+	if !ok {
+		return Order{}, errors.Errorf("unsupported order type '%s' of order '%d'", o.OrderType, o.OrderID)
+	}
+
+	trailing := orderType == Trail || orderType == TrailLimit
+	if trailing && (m.TrailingAmt == nil || m.TrailingType == "") {
+		return Order{}, errors.Errorf("trailing amount and type are required to modify trailing order '%d'", o.OrderID)
+	}
+
+	var side OrderSide
+	switch strings.ToUpper(o.Side) {
+	case "B", "BUY":
+		side = Buy
+	case "S", "SELL":
+		side = Sell
+	default:
+		return Order{}, errors.Errorf("unsupported side '%s' of order '%d'", o.Side, o.OrderID)
+	}
+
+	conid := o.Conid
+	if conid == 0 {
+		// conidex is the conid, optionally followed by @exchange
+		var err error
+		if conid, err = strconv.Atoi(strings.SplitN(o.Conidex, "@", 2)[0]); err != nil {
+			return Order{}, errors.Errorf("invalid conidex '%s' of order '%d'", o.Conidex, o.OrderID)
+		}
+	}
+
+	order := Order{
+		AcctID:          o.Account,
+		Conid:           conid,
+		OrderType:       orderType,
+		ListingExchange: o.ListingExchange,
+		OutsideRTH:      o.OutsideRth,
+		Side:            side,
+		Ticker:          o.Symbol,
+		Tif:             TimeInForce(o.Tif),
+	}
+
+	var err error
+	if order.Quantity, err = parseOrderStatusNumber(o.TotalSize); err != nil {
+		return Order{}, errors.Wrapf(err, "invalid size of order '%d'", o.OrderID)
+	}
+	if order.Price, err = parseOrderStatusNumber(o.LimitPrice); err != nil {
+		return Order{}, errors.Wrapf(err, "invalid limit price of order '%d'", o.OrderID)
+	}
+	stopPrice, err := parseOrderStatusNumber(o.StopPrice)
+	if err != nil {
+		return Order{}, errors.Wrapf(err, "invalid stop price of order '%d'", o.OrderID)
+	}
+	if m.AuxPrice != nil {
+		stopPrice = *m.AuxPrice
+	}
+
+	// STP orders carry their stop price in price, auxPrice is only used by stop limit and trailing orders
+	if orderType == Stop {
+		order.Price = stopPrice
+	} else {
+		order.AuxPrice = stopPrice
+	}
+
+	if m.Price != nil {
+		order.Price = *m.Price
+	}
+	if m.Quantity != nil {
+		order.Quantity = *m.Quantity
+	}
+	if m.Tif != "" {
+		order.Tif = m.Tif
+	}
+	if m.OutsideRTH != nil {
+		order.OutsideRTH = *m.OutsideRTH
+	}
+	if trailing {
+		order.TrailingAmt = *m.TrailingAmt
+		order.TrailingType = m.TrailingType
+	}
+
+	return order, nil
+}
+
+func parseOrderStatusNumber(s string) (float64, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	if s == "" {
+		return 0, nil
+	}
+
+	return strconv.ParseFloat(s, 64)
+}
+
+/*
+ModifyOrder - Modifies a working order keeping its queue priority where the
+exchange allows. Confirmations are returned as with PlaceOrders and answered
+with PlaceOrderReply.
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Order/paths/~1iserver~1account~1%7BaccountId%7D~1order~1%7BorderId%7D/post
+*/
+func (c *client) ModifyOrder(accountID, orderID string, order Order) ([]PlaceOrders, error) {
+	resp, err := c.post(substituteParam(modifyOrderPath,
+		param{
+			key:   "accountId",
+			value: accountID,
+		},
+		param{
+			key:   "orderId",
+			value: orderID,
+		},
+	), order)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderResp []PlaceOrders
+	if err := json.Unmarshal(v, &orderResp); err != nil {
+		return nil, err
+	}
+
+	return orderResp, nil
+}
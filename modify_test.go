@@ -0,0 +1,274 @@
+package ibweb
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModifyOrderIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	liveOrders, err := c.LiveOrders()
+	if !assert.Nil(t, err) || !assert.Greater(t, len(liveOrders.Orders), 0) {
+		t.FailNow()
+	}
+
+	orderID := strconv.Itoa(liveOrders.Orders[0].OrderID)
+	status, err := c.OrderStatus(orderID)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	tif := TimeInForce(GoodTillCanceled)
+	order, err := status.Modify(OrderModification{Tif: tif})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	_, err = c.ModifyOrder(status.Account, orderID, order)
+	assert.Nil(t, err)
+}
+
+func TestModifyOrderUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to modify",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to modify order")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to modify order",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read order")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read order",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/modify_order.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(modifyOrderPath, param{key: "accountId", value: "DU123456"}, param{key: "orderId", value: "1002507355"})), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.ModifyOrder("DU123456", "1002507355", Order{Conid: 265598, OrderType: Limit, Side: Buy, Price: 187.25, Quantity: 100})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestOrderStatusModifyUnit(t *testing.T) {
+	status := OrderStatus{
+		OrderID:         1002507355,
+		Conidex:         "265598@SMART",
+		Symbol:          "AAPL",
+		Side:            "S",
+		ListingExchange: "NASDAQ.NMS",
+		TotalSize:       "1,200.0",
+		Account:         "DU123456",
+		OrderType:       "Stop Limit",
+		LimitPrice:      "187.10",
+		StopPrice:       "187.25",
+		Tif:             "DAY",
+	}
+
+	price, quantity, outsideRTH := 186.5, 0.5, true
+	order, err := status.Modify(OrderModification{
+		Price:      &price,
+		Quantity:   &quantity,
+		Tif:        GoodTillCanceled,
+		OutsideRTH: &outsideRTH,
+	})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, Order{
+		AcctID:          "DU123456",
+		Conid:           265598,
+		OrderType:       StopLimit,
+		ListingExchange: "NASDAQ.NMS",
+		OutsideRTH:      true,
+		Price:           186.5,
+		AuxPrice:        187.25,
+		Side:            Sell,
+		Ticker:          "AAPL",
+		Tif:             GoodTillCanceled,
+		Quantity:        0.5,
+	}, order)
+
+	order, err = status.Modify(OrderModification{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, 187.1, order.Price)
+		assert.Equal(t, 1200.0, order.Quantity)
+		assert.Equal(t, TimeInForce("DAY"), order.Tif)
+	}
+
+	tests := []struct {
+		name            string
+		modify          func(s *OrderStatus)
+		wantErrContains string
+	}{
+		{"rejects orders that are not editable", func(s *OrderStatus) { s.OrderNotEditable = true }, "not editable"},
+		{"rejects unknown order types", func(s *OrderStatus) { s.OrderType = "PEG MID" }, "unsupported order type"},
+		{"rejects unknown sides", func(s *OrderStatus) { s.Side = "X" }, "unsupported side"},
+		{"rejects invalid conidex", func(s *OrderStatus) { s.Conidex = "AAPL" }, "invalid conidex"},
+		{"rejects invalid sizes", func(s *OrderStatus) { s.TotalSize = "n/a" }, "invalid size"},
+		{"requires the trailing amount of trailing orders", func(s *OrderStatus) { s.OrderType = "TRAILLMT" }, "trailing amount and type are required"},
+	}
+
+	for _, tc := range tests {
+		s := status
+		tc.modify(&s)
+		_, err := s.Modify(OrderModification{})
+		assertError(t, true, tc.wantErrContains, err)
+	}
+
+	trailing := status
+	trailing.OrderType = "TRAIL"
+	trailing.LimitPrice = ""
+	trailingAmt := 1.5
+	order, err = trailing.Modify(OrderModification{TrailingAmt: &trailingAmt, TrailingType: "amt"})
+	if assert.Nil(t, err) {
+		assert.Equal(t, Trail, order.OrderType)
+		assert.Equal(t, 187.25, order.AuxPrice)
+		assert.Equal(t, 1.5, order.TrailingAmt)
+		assert.Equal(t, "amt", order.TrailingType)
+	}
+
+	_, err = trailing.Modify(OrderModification{TrailingAmt: &trailingAmt})
+	assertError(t, true, "trailing amount and type are required", err)
+}
+
+func TestOrderStatusModifyStopUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	var body map[string]interface{}
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(modifyOrderPath, param{key: "accountId", value: "DU123456"}, param{key: "orderId", value: "1002507356"})),
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return nil, err
+			}
+
+			return httpmock.NewStringResponse(200, `[{"order_id":"1002507356","order_status":"PreSubmitted"}]`), nil
+		})
+
+	status := OrderStatus{
+		OrderID:   1002507356,
+		Conid:     265598,
+		Side:      "SELL",
+		TotalSize: "100.0",
+		Account:   "DU123456",
+		OrderType: "STP",
+		StopPrice: "185.00",
+		Tif:       "GTC",
+	}
+
+	order, err := status.Modify(OrderModification{})
+	if assert.Nil(t, err) {
+		assert.Equal(t, 185.0, order.Price)
+		assert.Equal(t, 0.0, order.AuxPrice)
+	}
+
+	stop := 184.5
+	order, err = status.Modify(OrderModification{AuxPrice: &stop})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	c := New("http://127.0.0.1:5555")
+	_, err = c.ModifyOrder("DU123456", "1002507356", order)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "STP", body["orderType"])
+	assert.Equal(t, 184.5, body["price"])
+	assert.NotContains(t, body, "auxPrice")
+	assert.Equal(t, 100.0, body["quantity"])
+}
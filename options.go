@@ -0,0 +1,233 @@
+package ibweb
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	occDateLayout         = "060102"
+	occRootLength         = 6
+	occStrikeLength       = 8
+	occStrikeScale        = 1000
+	descriptionDateLayout = "Jan 02 '06"
+	monthLayout           = "Jan06"
+	maturityLayout        = "20060102"
+)
+
+/*
+OptionSymbol - Option contract identified by its underlying, expiry, right and strike
+*/
+type OptionSymbol struct {
+	Underlying string
+	Expiry     time.Time
+	Right      Right
+	Strike     float64
+}
+
+/*
+ParseOptionSymbol - Parses an option symbol in either OCC format, padded
+(AAPL  240119C00190000) or compact (AAPL240119C00190000), which is also the IB
+local symbol of US options, or the IB description format (AAPL JAN 19 '24 190 Call).
+*/
+func ParseOptionSymbol(s string) (OptionSymbol, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "'") {
+		return parseOptionDescription(s)
+	}
+
+	return parseOCC(s)
+}
+
+func parseOCC(s string) (OptionSymbol, error) {
+	const suffixLength = len(occDateLayout) + 1 + occStrikeLength
+	if len(s) <= suffixLength {
+		return OptionSymbol{}, errors.Errorf("invalid OCC symbol '%s'", s)
+	}
+
+	root := strings.TrimSpace(s[:len(s)-suffixLength])
+	suffix := s[len(s)-suffixLength:]
+	if root == "" || len(root) > occRootLength {
+		return OptionSymbol{}, errors.Errorf("invalid OCC symbol '%s': bad root", s)
+	}
+
+	expiry, err := time.Parse(occDateLayout, suffix[:len(occDateLayout)])
+	if err != nil {
+		return OptionSymbol{}, errors.Wrapf(err, "invalid OCC symbol '%s': bad expiry", s)
+	}
+
+	right := Right(suffix[len(occDateLayout) : len(occDateLayout)+1])
+	if right != Call && right != Put {
+		return OptionSymbol{}, errors.Errorf("invalid OCC symbol '%s': bad right '%s'", s, right)
+	}
+
+	strike, err := strconv.Atoi(suffix[len(occDateLayout)+1:])
+	if err != nil {
+		return OptionSymbol{}, errors.Wrapf(err, "invalid OCC symbol '%s': bad strike", s)
+	}
+
+	return OptionSymbol{
+		Underlying: root,
+		Expiry:     expiry,
+		Right:      right,
+		Strike:     float64(strike) / occStrikeScale,
+	}, nil
+}
+
+func parseOptionDescription(s string) (OptionSymbol, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 6 {
+		return OptionSymbol{}, errors.Errorf("invalid option description '%s'", s)
+	}
+
+	expiry, err := time.Parse(descriptionDateLayout, strings.Join(fields[1:4], " "))
+	if err != nil {
+		return OptionSymbol{}, errors.Wrapf(err, "invalid option description '%s': bad expiry", s)
+	}
+
+	strike, err := strconv.ParseFloat(fields[4], 64)
+	if err != nil {
+		return OptionSymbol{}, errors.Wrapf(err, "invalid option description '%s': bad strike", s)
+	}
+
+	var right Right
+	switch strings.ToUpper(fields[5]) {
+	case "CALL", string(Call):
+		right = Call
+	case "PUT", string(Put):
+		right = Put
+	default:
+		return OptionSymbol{}, errors.Errorf("invalid option description '%s': bad right '%s'", s, fields[5])
+	}
+
+	return OptionSymbol{
+		Underlying: fields[0],
+		Expiry:     expiry,
+		Right:      right,
+		Strike:     strike,
+	}, nil
+}
+
+// OCC - formats the symbol in padded OCC format, the IB local symbol of US options
+func (o OptionSymbol) OCC() string {
+	return fmt.Sprintf("%-*s%s%s%0*d",
+		occRootLength, o.Underlying,
+		o.Expiry.Format(occDateLayout),
+		o.Right,
+		occStrikeLength, int64(math.Round(o.Strike*occStrikeScale)),
+	)
+}
+
+// Description - formats the symbol like the IB contract description, e.g. AAPL JAN 19 '24 190 Call
+func (o OptionSymbol) Description() string {
+	right := "Call"
+	if o.Right == Put {
+		right = "Put"
+	}
+
+	return fmt.Sprintf("%s %s %s %s",
+		o.Underlying,
+		strings.ToUpper(o.Expiry.Format(descriptionDateLayout)),
+		strconv.FormatFloat(o.Strike, 'f', -1, 64),
+		right,
+	)
+}
+
+// Month - formats the expiry month as used by SearchStrikes and SecurityDefinitionInfo, e.g. JAN24
+func (o OptionSymbol) Month() string {
+	return strings.ToUpper(o.Expiry.Format(monthLayout))
+}
+
+// Matches - reports if the security definition is the option contract of the symbol
+func (o OptionSymbol) Matches(info SecurityDefinitionInfo) bool {
+	return info.Right == string(o.Right) &&
+		math.Abs(info.Strike-o.Strike) < 1e-6 &&
+		info.MaturityDate == o.Expiry.Format(maturityLayout)
+}
+
+/*
+ResolveOption - Resolves the IB security definition of an option symbol by
+searching for the underlying, checking the strike is listed and matching the
+security definitions of the expiry month.
+*/
+func ResolveOption(c Client, symbol OptionSymbol) (*SecurityDefinitionInfo, error) {
+	contracts, err := c.SearchContracts(SearchContractsInput{
+		Symbol:  symbol.Underlying,
+		SecType: Options,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var underlying string
+	for _, contract := range contracts {
+		if !strings.EqualFold(contract.Symbol, symbol.Underlying) {
+			continue
+		}
+
+		for _, section := range contract.Sections {
+			if section.SecType == Options {
+				underlying = contract.Conid
+				break
+			}
+		}
+
+		if underlying != "" {
+			break
+		}
+	}
+
+	if underlying == "" {
+		return nil, errors.Errorf("no optionable contract found for '%s'", symbol.Underlying)
+	}
+
+	strikes, err := c.SearchStrikes(SearchStrikesInput{
+		ConID:   underlying,
+		SecType: Options,
+		Month:   symbol.Month(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	listed := strikes.Call
+	if symbol.Right == Put {
+		listed = strikes.Put
+	}
+
+	found := false
+	for _, strike := range listed {
+		if math.Abs(strike-symbol.Strike) < 1e-6 {
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return nil, errors.Errorf("strike %v is not listed for '%s' in %s", symbol.Strike, symbol.Underlying, symbol.Month())
+	}
+
+	infos, err := c.SecurityDefinitionInfo(SecurityDefinitionInfoInput{
+		ConID:   underlying,
+		SecType: Options,
+		Month:   symbol.Month(),
+		Strike:  symbol.Strike,
+		Right:   string(symbol.Right),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range infos {
+		if symbol.Matches(info) {
+			return &info, nil
+		}
+	}
+
+	return nil, errors.Errorf("no contract found for option '%s'", symbol.OCC())
+}
@@ -0,0 +1,95 @@
+package ibweb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseOptionSymbolUnit(t *testing.T) {
+	aapl := OptionSymbol{
+		Underlying: "AAPL",
+		Expiry:     time.Date(2024, 1, 19, 0, 0, 0, 0, time.UTC),
+		Right:      Call,
+		Strike:     190,
+	}
+
+	tests := []struct {
+		name            string
+		input           string
+		want            OptionSymbol
+		wantErrContains string
+	}{
+		{"parses padded OCC", "AAPL  240119C00190000", aapl, ""},
+		{"parses compact OCC", "AAPL240119C00190000", aapl, ""},
+		{"parses description", "AAPL JAN 19 '24 190 Call", aapl, ""},
+		{
+			"parses fractional strike put",
+			"SPY   231215P00452500",
+			OptionSymbol{Underlying: "SPY", Expiry: time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC), Right: Put, Strike: 452.5},
+			"",
+		},
+		{"handles short symbol", "C00190000", OptionSymbol{}, "invalid OCC symbol"},
+		{"handles long root", "ABCDEFG240119C00190000", OptionSymbol{}, "bad root"},
+		{"handles bad expiry", "AAPL  241319C00190000", OptionSymbol{}, "bad expiry"},
+		{"handles bad right", "AAPL  240119X00190000", OptionSymbol{}, "bad right"},
+		{"handles bad strike", "AAPL  240119C0019000X", OptionSymbol{}, "bad strike"},
+		{"handles bad description", "AAPL JAN 19 '24 Call", OptionSymbol{}, "invalid option description"},
+		{"handles bad description right", "AAPL JAN 19 '24 190 X", OptionSymbol{}, "bad right"},
+	}
+
+	for _, tc := range tests {
+		got, err := ParseOptionSymbol(tc.input)
+		if !assertError(t, tc.wantErrContains != "", tc.wantErrContains, err) {
+			t.Log(tc.name)
+			continue
+		}
+
+		assert.Equal(t, tc.want, got, tc.name)
+	}
+
+	assert.Equal(t, "AAPL  240119C00190000", aapl.OCC())
+	assert.Equal(t, "AAPL JAN 19 '24 190 Call", aapl.Description())
+	assert.Equal(t, "JAN24", aapl.Month())
+	assert.Equal(t, "SPY   231215P00452500", OptionSymbol{
+		Underlying: "SPY", Expiry: time.Date(2023, 12, 15, 0, 0, 0, 0, time.UTC), Right: Put, Strike: 452.5,
+	}.OCC())
+}
+
+func TestResolveOptionUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", searchContractsPath),
+		httpmock.NewStringResponder(200, httpmock.File("./testdata/contracts.json").String()))
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", searchStrikesPath),
+		httpmock.NewStringResponder(200, httpmock.File("./testdata/search_strikes.json").String()))
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", secDefInfoPath),
+		httpmock.NewStringResponder(200, httpmock.File("./testdata/secdefinfo.json").String()))
+
+	c := New("http://127.0.0.1:5555")
+
+	symbol, err := ParseOptionSymbol("AAPL  231201P00190000")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	info, err := ResolveOption(c, symbol)
+	if assert.Nil(t, err) {
+		assert.Equal(t, 659250825, info.Conid)
+	}
+
+	symbol.Strike = 191.5
+	_, err = ResolveOption(c, symbol)
+	assertError(t, true, "is not listed", err)
+
+	symbol.Underlying = "ZZZZ"
+	_, err = ResolveOption(c, symbol)
+	assertError(t, true, "no optionable contract found", err)
+}
@@ -3,11 +3,15 @@ package ibweb
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
 const (
 	placeOrdersPath     = "v1/api/iserver/account/{accountId}/orders"
 	cancelOrderPath     = "v1/api/iserver/account/{accountId}/order/{orderId}"
+	modifyOrderPath     = "v1/api/iserver/account/{accountId}/order/{orderId}"
 	placeOrderReplyPath = "v1/api/iserver/reply/{replyid}"
 	liveOrdersPath      = "v1/api/iserver/account/orders"
 	orderStatusPath     = "v1/api/iserver/account/order/status/{orderId}"
@@ -57,33 +61,104 @@ Order -
 Link: https://www.interactivebrokers.com/api/doc.html#tag/Order/paths/~1iserver~1account~1%7BaccountId%7D~1orders/post
 */
 type Order struct {
-	AcctID             string      `json:"acctId,omitempty"`
-	Conid              int         `json:"conid,omitempty"`
-	Conidex            string      `json:"conidex,omitempty"`
-	SecType            string      `json:"secType,omitempty"`
-	COID               string      `json:"cOID,omitempty"`
-	ParentID           string      `json:"parentId,omitempty"`
-	OrderType          OrderType   `json:"orderType,omitempty"`
-	ListingExchange    string      `json:"listingExchange,omitempty"`
-	IsSingleGroup      bool        `json:"isSingleGroup,omitempty"`
-	OutsideRTH         bool        `json:"outsideRTH,omitempty"`
-	Price              int         `json:"price,omitempty"`
-	AuxPrice           interface{} `json:"auxPrice,omitempty"`
-	Side               OrderSide   `json:"side,omitempty"`
-	Ticker             string      `json:"ticker,omitempty"`
-	Tif                TimeInForce `json:"tif,omitempty"`
-	TrailingAmt        int         `json:"trailingAmt,omitempty"`
-	TrailingType       string      `json:"trailingType,omitempty"`
-	Referrer           string      `json:"referrer,omitempty"`
-	Quantity           int         `json:"quantity,omitempty"`
-	CashQty            int         `json:"cashQty,omitempty"`
-	FxQty              int         `json:"fxQty,omitempty"`
-	UseAdaptive        bool        `json:"useAdaptive,omitempty"`
-	IsCcyConv          bool        `json:"isCcyConv,omitempty"`
-	AllocationMethod   string      `json:"allocationMethod,omitempty"`
-	Strategy           string      `json:"strategy,omitempty"`
-	StrategyParameters struct {
-	} `json:"strategyParameters,omitempty"`
+	AcctID             string             `json:"acctId,omitempty"`
+	Conid              int                `json:"conid,omitempty"`
+	Conidex            string             `json:"conidex,omitempty"`
+	SecType            string             `json:"secType,omitempty"`
+	COID               string             `json:"cOID,omitempty"`
+	ParentID           string             `json:"parentId,omitempty"`
+	OrderType          OrderType          `json:"orderType,omitempty"`
+	ListingExchange    string             `json:"listingExchange,omitempty"`
+	IsSingleGroup      bool               `json:"isSingleGroup,omitempty"`
+	OutsideRTH         bool               `json:"outsideRTH,omitempty"`
+	Price              float64            `json:"price,omitempty"`
+	AuxPrice           float64            `json:"auxPrice,omitempty"`
+	Side               OrderSide          `json:"side,omitempty"`
+	Ticker             string             `json:"ticker,omitempty"`
+	Tif                TimeInForce        `json:"tif,omitempty"`
+	TrailingAmt        float64            `json:"trailingAmt,omitempty"`
+	TrailingType       string             `json:"trailingType,omitempty"`
+	Referrer           string             `json:"referrer,omitempty"`
+	Quantity           float64            `json:"quantity,omitempty"`
+	CashQty            float64            `json:"cashQty,omitempty"`
+	FxQty              float64            `json:"fxQty,omitempty"`
+	UseAdaptive        bool               `json:"useAdaptive,omitempty"`
+	IsCcyConv          bool               `json:"isCcyConv,omitempty"`
+	AllocationMethod   string             `json:"allocationMethod,omitempty"`
+	Strategy           string             `json:"strategy,omitempty"`
+	StrategyParameters StrategyParameters `json:"strategyParameters,omitempty"`
+}
+
+/*
+RoundToTick - returns the order with Price and AuxPrice rounded to the tick size
+of secDef. An amount TrailingAmt is rounded to the tick at the stop price, or the
+price without one, a percentage TrailingAmt is left as it is.
+*/
+func (o Order) RoundToTick(secDef SecDef) Order {
+	o.Price = secDef.RoundPrice(o.Price)
+	o.AuxPrice = secDef.RoundPrice(o.AuxPrice)
+	if o.trailingAmount() {
+		reference := o.AuxPrice
+		if reference == 0 {
+			reference = o.Price
+		}
+		o.TrailingAmt = roundToTick(o.TrailingAmt, secDef.TickSize(reference))
+	}
+	return o
+}
+
+// trailingAmount - the trailing amount is a price offset rather than a percentage
+func (o Order) trailingAmount() bool {
+	return o.TrailingAmt != 0 && strings.EqualFold(o.TrailingType, "amt")
+}
+
+/*
+RoundOrders - Rounds the prices and trailing amounts of orders to the tick size of their contracts,
+using the increment rules from SecDefByConids. Combo orders are left as they are
+since their net price does not follow the increment rules of any leg. Fails when
+a contract has no security definition.
+*/
+func RoundOrders(c Client, orders []Order) ([]Order, error) {
+	var conids []int
+	for _, order := range orders {
+		if order.roundable() {
+			conids = append(conids, order.Conid)
+		}
+	}
+
+	rounded := make([]Order, len(orders))
+	copy(rounded, orders)
+	if len(conids) == 0 {
+		return rounded, nil
+	}
+
+	secDefs, err := c.SecDefByConids(conids)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get tick sizes")
+	}
+
+	for i, order := range rounded {
+		if !order.roundable() {
+			continue
+		}
+
+		secDef, ok := secDefs.SecDefs[order.Conid]
+		if !ok {
+			return nil, errors.Errorf("no security definition for conid '%d'", order.Conid)
+		}
+		rounded[i] = order.RoundToTick(secDef)
+	}
+
+	return rounded, nil
+}
+
+// roundable - the order has a price and is placed on a single contract rather than a combo
+func (o Order) roundable() bool {
+	if o.Conid == 0 || o.Conidex != "" {
+		return false
+	}
+
+	return o.Price != 0 || o.AuxPrice != 0 || o.trailingAmount()
 }
 
 /*
@@ -96,6 +171,7 @@ type PlaceOrders struct {
 	EncryptedMessage string   `json:"encrypt_message"`
 	ID               string   `json:"id"`
 	Message          []string `json:"message"`
+	MessageIDs       []string `json:"messageIds"`
 }
 
 /*
@@ -153,7 +229,7 @@ type LiveOrders struct {
 		OrderRef           string  `json:"order_ref"`
 		Side               string  `json:"side"`
 		TimeInForce        string  `json:"timeInForce"`
-		Price              int     `json:"price"`
+		Price              float64 `json:"price"`
 		BgColor            string  `json:"bgColor"`
 		FgColor            string  `json:"fgColor"`
 	} `json:"orders"`
@@ -169,6 +245,7 @@ type OrderStatus struct {
 	RequestID                    string `json:"request_id"`
 	OrderID                      int    `json:"order_id"`
 	Conidex                      string `json:"conidex"`
+	Conid                        int    `json:"conid"`
 	Symbol                       string `json:"symbol"`
 	Side                         string `json:"side"`
 	ContractDescription1         string `json:"contract_description_1"`
@@ -210,7 +287,8 @@ type OrderStatus struct {
 }
 
 /*
-PlaceOrders - Places orders
+PlaceOrders - Places orders. Prices are sent as given, the gateway rejects those
+off the tick size of the contract so round them with RoundOrders first.
 Link: https://www.interactivebrokers.com/api/doc.html#tag/Order/paths/~1iserver~1account~1%7BaccountId%7D~1orders/post
 */
 func (c *client) PlaceOrders(accountID string, input PlaceOrdersInput) ([]PlaceOrders, error) {
@@ -2,6 +2,7 @@ package ibweb
 
 import (
 	"crypto/tls"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -576,3 +577,68 @@ func TestOrderStatusUnit(t *testing.T) {
 		httpmock.DeactivateAndReset()
 	}
 }
+
+func TestOrderJSONUnit(t *testing.T) {
+	v, err := json.Marshal(Order{
+		Conid:     265598,
+		OrderType: StopLimit,
+		Side:      Buy,
+		Price:     187.25,
+		AuxPrice:  187.1,
+		Quantity:  0.5,
+	})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.JSONEq(t, `{"conid":265598,"orderType":"STOP_LIMIT","side":"BUY","price":187.25,"auxPrice":187.1,"quantity":0.5}`, string(v))
+}
+
+func TestRoundOrdersUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	v, err := os.ReadFile("./testdata/secdef.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", secDefPath),
+		httpmock.NewBytesResponder(200, v))
+
+	c := New("http://127.0.0.1:5555")
+	orders, err := RoundOrders(c, []Order{
+		{Conid: 659248794, OrderType: Limit, Price: 4.12, Quantity: 1.5},
+		{Conid: 659248794, OrderType: Stop, AuxPrice: 2.004},
+		{Conid: 265598, OrderType: Market, Quantity: 2},
+		{Conid: 659248794, OrderType: Trail, AuxPrice: 4.12, TrailingAmt: 0.26, TrailingType: "amt"},
+		{Conid: 659248794, OrderType: Trail, AuxPrice: 4.12, TrailingAmt: 0.26, TrailingType: "%"},
+	})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, 4.1, orders[0].Price)
+	assert.Equal(t, 1.5, orders[0].Quantity)
+	assert.Equal(t, 2.0, orders[1].AuxPrice)
+	assert.Equal(t, Order{Conid: 265598, OrderType: Market, Quantity: 2}, orders[2])
+	assert.Equal(t, 0.25, orders[3].TrailingAmt)
+	assert.Equal(t, 0.26, orders[4].TrailingAmt)
+
+	_, err = RoundOrders(c, []Order{{Conid: 1, OrderType: Limit, Price: 1}})
+	assertError(t, true, "no security definition for conid '1'", err)
+
+	combo, err := NewCombo().
+		AddSecurityDefinition(SecurityDefinitionInfo{Conid: 659248794, Currency: "USD"}, 1, Buy).
+		AddSecurityDefinition(SecurityDefinitionInfo{Conid: 1, Currency: "USD"}, 1, Sell).
+		Apply(Order{OrderType: Limit, Price: -0.123, Quantity: 1})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	orders, err = RoundOrders(c, []Order{combo, {Conid: 659248794, OrderType: Limit, Price: 4.12}})
+	assert.Nil(t, err)
+	assert.Equal(t, combo, orders[0])
+	assert.Equal(t, 4.1, orders[1].Price)
+}
@@ -0,0 +1,168 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	previewOrdersPath = "v1/api/iserver/account/{accountId}/orders/whatif"
+)
+
+/*
+OrderPreview - Commission and the impact on the account of orders if they were placed
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Order/paths/~1iserver~1account~1%7BaccountId%7D~1orders~1whatif/post
+*/
+type OrderPreview struct {
+	Amount      PreviewAmount `json:"amount"`
+	Equity      PreviewChange `json:"equity"`
+	Initial     PreviewChange `json:"initial"`
+	Maintenance PreviewChange `json:"maintenance"`
+	Position    PreviewChange `json:"position"`
+	// Warn - warning shown before placing, e.g. 21/You are trying to submit an order without having market data
+	Warn  string `json:"warn"`
+	Error string `json:"error"`
+}
+
+// PreviewAmount - Value and commission of previewed orders, sent as e.g. 23,000 USD (100 Shares)
+type PreviewAmount struct {
+	Amount     float64
+	Commission float64
+	Total      float64
+	Currency   string
+}
+
+func (p *PreviewAmount) UnmarshalJSON(v []byte) error {
+	var raw struct {
+		Amount     string `json:"amount"`
+		Commission string `json:"commission"`
+		Total      string `json:"total"`
+	}
+	if err := json.Unmarshal(v, &raw); err != nil {
+		return err
+	}
+
+	var amount PreviewAmount
+	var err error
+	if amount.Amount, amount.Currency, err = parsePreviewMoney(raw.Amount); err != nil {
+		return errors.Wrap(err, "invalid preview amount")
+	}
+	if amount.Commission, _, err = parsePreviewMoney(raw.Commission); err != nil {
+		return errors.Wrap(err, "invalid preview commission")
+	}
+	if amount.Total, _, err = parsePreviewMoney(raw.Total); err != nil {
+		return errors.Wrap(err, "invalid preview total")
+	}
+
+	*p = amount
+	return nil
+}
+
+// PreviewChange - Value before the orders, its change and the value after
+type PreviewChange struct {
+	Current float64
+	Change  float64
+	After   float64
+}
+
+func (p *PreviewChange) UnmarshalJSON(v []byte) error {
+	var raw struct {
+		Current string `json:"current"`
+		Change  string `json:"change"`
+		After   string `json:"after"`
+	}
+	if err := json.Unmarshal(v, &raw); err != nil {
+		return err
+	}
+
+	var change PreviewChange
+	var err error
+	if change.Current, _, err = parsePreviewMoney(raw.Current); err != nil {
+		return errors.Wrap(err, "invalid preview current value")
+	}
+	if change.Change, _, err = parsePreviewMoney(raw.Change); err != nil {
+		return errors.Wrap(err, "invalid preview change")
+	}
+	if change.After, _, err = parsePreviewMoney(raw.After); err != nil {
+		return errors.Wrap(err, "invalid preview value after")
+	}
+
+	*p = change
+	return nil
+}
+
+// parsePreviewMoney - parses a number followed by an optional currency and description, e.g. 1.05 USD
+func parsePreviewMoney(s string) (float64, string, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, "", nil
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(fields[0], ",", ""), 64)
+	if err != nil {
+		return 0, "", errors.Errorf("invalid value '%s'", s)
+	}
+
+	var currency string
+	if len(fields) > 1 && !strings.HasPrefix(fields[1], "(") {
+		currency = fields[1]
+	}
+
+	return value, currency, nil
+}
+
+// Warnings - gets the warning messages without their codes
+func (o OrderPreview) Warnings() []string {
+	var warnings []string
+	for _, line := range strings.Split(o.Warn, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if i := strings.Index(line, "/"); i > 0 {
+			if _, err := strconv.Atoi(line[:i]); err == nil {
+				line = line[i+1:]
+			}
+		}
+		warnings = append(warnings, line)
+	}
+
+	return warnings
+}
+
+/*
+PreviewOrders - Previews orders without placing them, the same input can then be
+passed to PlaceOrders
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Order/paths/~1iserver~1account~1%7BaccountId%7D~1orders~1whatif/post
+*/
+func (c *client) PreviewOrders(accountID string, input PlaceOrdersInput) (*OrderPreview, error) {
+	resp, err := c.post(substituteParam(previewOrdersPath, param{
+		key:   "accountId",
+		value: accountID,
+	}), input)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderPreview OrderPreview
+	if err := json.Unmarshal(v, &orderPreview); err != nil {
+		return nil, err
+	}
+
+	return &orderPreview, nil
+}
@@ -0,0 +1,166 @@
+package ibweb
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewOrdersIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	portfolioAccounts, err := c.PortfolioAccounts()
+	if !assert.Nil(t, err) || !assert.Greater(t, len(portfolioAccounts), 0) {
+		t.FailNow()
+	}
+
+	preview, err := c.PreviewOrders(portfolioAccounts[0].AccountID, PlaceOrdersInput{
+		Orders: []Order{
+			{
+				AcctID:    portfolioAccounts[0].AccountID,
+				Conid:     265598,
+				Quantity:  1,
+				OrderType: Limit,
+				Price:     100.5,
+				Side:      Buy,
+				Tif:       Dat,
+			},
+		},
+	})
+	assert.Nil(t, err)
+	assert.NotNil(t, preview)
+}
+
+func TestPreviewOrdersUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to preview",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to preview orders")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to preview orders",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read orders")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read orders",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/preview_orders.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(previewOrdersPath, param{key: "accountId", value: "DU123456"})), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.PreviewOrders("DU123456", PlaceOrdersInput{Orders: []Order{{Conid: 265598, OrderType: Limit, Side: Buy, Price: 187.25, Quantity: 100}}})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestOrderPreviewDecodeUnit(t *testing.T) {
+	v, err := os.ReadFile("./testdata/preview_orders.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var preview OrderPreview
+	if !assert.Nil(t, json.Unmarshal(v, &preview)) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, PreviewAmount{Amount: 18725, Commission: 1, Total: 18726, Currency: "USD"}, preview.Amount)
+	assert.Equal(t, PreviewChange{Current: 211460, Change: -1, After: 211459}, preview.Equity)
+	assert.Equal(t, PreviewChange{Current: 41229, Change: 4681, After: 45910}, preview.Initial)
+	assert.Equal(t, PreviewChange{Current: 37481, Change: 4681, After: 42162}, preview.Maintenance)
+	assert.Equal(t, PreviewChange{Current: 0, Change: 100, After: 100}, preview.Position)
+	assert.Equal(t, []string{"You are trying to submit an order without having market data for this instrument."}, preview.Warnings())
+	assert.Empty(t, preview.Error)
+
+	err = json.Unmarshal([]byte(`{"amount":{"amount":"n/a"}}`), &preview)
+	assertError(t, true, "invalid preview amount", err)
+}
@@ -0,0 +1,226 @@
+package ibweb
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const resampleDay = 24 * time.Hour
+
+// resampleEpoch - Monday multi-day buckets are counted from, so weekly bars start on Mondays
+var resampleEpoch = time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)
+
+/*
+Resampler - Aggregates bars into bars of a longer Duration. Intraday buckets are
+aligned to midnight in Location and buckets of whole days to Mondays. When a
+Schedule is set bars outside its sessions are dropped, intraday buckets are
+aligned to the session open and daily buckets keyed by the day sessions open, in
+the exchange zone.
+*/
+type Resampler struct {
+	// Duration - length of the resampled bars, below a day it must divide a day
+	Duration time.Duration
+	// Location - zone buckets are aligned in without a Schedule, nil uses UTC
+	Location *time.Location
+	// Schedule - trading sessions buckets are aligned to
+	Schedule *TradingSchedule
+	// OutsideRth - align to sessions including outside regular trading hours
+	OutsideRth bool
+	// FillGaps - adds flat bars without volume at the previous close for empty buckets
+	FillGaps bool
+}
+
+// Resample - aggregates bars into resampled bars ordered by time
+func (r Resampler) Resample(bars []Bar) ([]Bar, error) {
+	if r.Duration <= 0 || (r.Duration < resampleDay && resampleDay%r.Duration != 0) || (r.Duration > resampleDay && r.Duration%resampleDay != 0) {
+		return nil, errors.Errorf("invalid resample duration %s", r.Duration)
+	}
+
+	loc := r.Location
+	if r.Schedule != nil {
+		var err error
+		if loc, err = r.Schedule.Location(); err != nil {
+			return nil, err
+		}
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	sorted := cleanBars(append([]Bar(nil), bars...))
+
+	var (
+		resampled []Bar
+		current   *bucket
+	)
+	for _, bar := range sorted {
+		start, ok, err := r.bucketStart(bar.Time.In(loc), loc)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		if current != nil && current.start.Equal(start) {
+			current.add(bar)
+			continue
+		}
+
+		if current != nil {
+			resampled = append(resampled, current.bar())
+
+			if r.FillGaps {
+				gaps, err := r.gaps(current.start, start, current.close)
+				if err != nil {
+					return nil, err
+				}
+				resampled = append(resampled, gaps...)
+			}
+		}
+
+		current = newBucket(start, bar)
+	}
+
+	if current != nil {
+		resampled = append(resampled, current.bar())
+	}
+
+	return resampled, nil
+}
+
+// bucketStart - gets the start of the bucket of t, false when t is outside the sessions
+func (r Resampler) bucketStart(t time.Time, loc *time.Location) (time.Time, bool, error) {
+	anchor := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+
+	if r.Schedule != nil {
+		session, ok, err := r.session(t)
+		if err != nil || !ok {
+			return time.Time{}, false, err
+		}
+
+		if r.Duration < resampleDay {
+			return session.Open.Add(t.Sub(session.Open) / r.Duration * r.Duration), true, nil
+		}
+
+		open := session.Open.In(loc)
+		anchor = time.Date(open.Year(), open.Month(), open.Day(), 0, 0, 0, 0, loc)
+	} else if r.Duration < resampleDay {
+		return anchor.Add(t.Sub(anchor) / r.Duration * r.Duration), true, nil
+	}
+
+	// count whole days from the epoch so buckets are stable across DST changes
+	days := int(time.Date(anchor.Year(), anchor.Month(), anchor.Day(), 0, 0, 0, 0, time.UTC).Sub(resampleEpoch) / resampleDay)
+	n := int(r.Duration / resampleDay)
+	offset := days % n
+	if offset < 0 {
+		offset += n
+	}
+
+	return anchor.AddDate(0, 0, -offset), true, nil
+}
+
+// session - gets the session containing t, sessions may open the day before
+func (r Resampler) session(t time.Time) (Session, bool, error) {
+	for _, d := range []time.Time{t, t.AddDate(0, 0, -1)} {
+		sessions, err := r.Schedule.SessionsOn(d, r.OutsideRth)
+		if err != nil {
+			return Session{}, false, err
+		}
+
+		for _, s := range sessions {
+			if s.Contains(t) {
+				return s, true, nil
+			}
+		}
+	}
+
+	return Session{}, false, nil
+}
+
+// gaps - gets flat bars for the empty buckets between from and to, exclusive
+func (r Resampler) gaps(from, to time.Time, last float64) ([]Bar, error) {
+	var gaps []Bar
+	for start := r.next(from); start.Before(to); start = r.next(start) {
+		if r.Schedule != nil {
+			var (
+				open bool
+				err  error
+			)
+			if r.Duration < resampleDay {
+				_, open, err = r.session(start)
+			} else {
+				var sessions []Session
+				sessions, err = r.Schedule.SessionsOn(start, r.OutsideRth)
+				open = len(sessions) > 0
+			}
+			if err != nil {
+				return nil, err
+			}
+			if !open {
+				continue
+			}
+		}
+
+		gaps = append(gaps, Bar{Time: start, Open: last, High: last, Low: last, Close: last, VWAP: last})
+	}
+
+	return gaps, nil
+}
+
+func (r Resampler) next(start time.Time) time.Time {
+	if r.Duration < resampleDay {
+		return start.Add(r.Duration)
+	}
+
+	return start.AddDate(0, 0, int(r.Duration/resampleDay))
+}
+
+// bucket - bars being aggregated into a resampled bar
+type bucket struct {
+	start                  time.Time
+	open, high, low, close float64
+	volume, notional       float64
+}
+
+func newBucket(start time.Time, bar Bar) *bucket {
+	b := &bucket{start: start, open: bar.Open, high: bar.High, low: bar.Low}
+	b.add(bar)
+	return b
+}
+
+func (b *bucket) add(bar Bar) {
+	if bar.High > b.high {
+		b.high = bar.High
+	}
+	if bar.Low < b.low {
+		b.low = bar.Low
+	}
+	b.close = bar.Close
+	b.volume += bar.Volume
+
+	// bars without a VWAP trade at their typical price
+	price := bar.VWAP
+	if price == 0 {
+		price = (bar.High + bar.Low + bar.Close) / 3
+	}
+	b.notional += price * bar.Volume
+}
+
+func (b *bucket) bar() Bar {
+	vwap := b.close
+	if b.volume > 0 {
+		vwap = b.notional / b.volume
+	}
+
+	return Bar{
+		Time:   b.start,
+		Open:   b.open,
+		High:   b.high,
+		Low:    b.low,
+		Close:  b.close,
+		Volume: b.volume,
+		VWAP:   vwap,
+	}
+}
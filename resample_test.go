@@ -0,0 +1,157 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// minuteBars - one bar a minute from start with rising prices and a volume of 10
+func minuteBars(start time.Time, n int) []Bar {
+	bars := make([]Bar, 0, n)
+	for i := 0; i < n; i++ {
+		price := 100 + float64(i)
+		bars = append(bars, Bar{
+			Time:   start.Add(time.Duration(i) * time.Minute),
+			Open:   price,
+			High:   price + 1,
+			Low:    price - 1,
+			Close:  price + 0.5,
+			Volume: 10,
+		})
+	}
+
+	return bars
+}
+
+func loadTestSchedule(t *testing.T) *TradingSchedule {
+	v, err := os.ReadFile("testdata/trading_schedule.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var schedules []TradingSchedule
+	if !assert.Nil(t, json.Unmarshal(v, &schedules)) {
+		t.FailNow()
+	}
+
+	return &schedules[0]
+}
+
+func barTimes(bars []Bar) []time.Time {
+	times := make([]time.Time, 0, len(bars))
+	for _, bar := range bars {
+		times = append(times, bar.Time)
+	}
+
+	return times
+}
+
+func TestResampleUnit(t *testing.T) {
+	start := time.Date(2023, 11, 27, 14, 30, 0, 0, time.UTC)
+	bars := minuteBars(start, 30)
+
+	// unordered input is sorted first
+	bars[0], bars[29] = bars[29], bars[0]
+
+	resampled, err := Resampler{Duration: 15 * time.Minute}.Resample(bars)
+	if !assert.Nil(t, err) || !assert.Len(t, resampled, 2) {
+		t.FailNow()
+	}
+
+	first := resampled[0]
+	assert.Equal(t, start, first.Time)
+	assert.Equal(t, 100.0, first.Open)
+	assert.Equal(t, 115.0, first.High)
+	assert.Equal(t, 99.0, first.Low)
+	assert.Equal(t, 114.5, first.Close)
+	assert.Equal(t, 150.0, first.Volume)
+	// typical prices rise by one a minute from 100.1666
+	assert.InDelta(t, 107+0.5/3, first.VWAP, 1e-9)
+	assert.Equal(t, start.Add(15*time.Minute), resampled[1].Time)
+
+	// resampled bars resample using their VWAP
+	hourly, err := Resampler{Duration: time.Hour}.Resample(resampled)
+	assert.Nil(t, err)
+	if assert.Len(t, hourly, 1) {
+		assert.Equal(t, time.Date(2023, 11, 27, 14, 0, 0, 0, time.UTC), hourly[0].Time)
+		assert.InDelta(t, 114.5+0.5/3, hourly[0].VWAP, 1e-9)
+	}
+
+	for _, d := range []time.Duration{0, 7 * time.Minute, 36 * time.Hour} {
+		_, err := Resampler{Duration: d}.Resample(bars)
+		assert.NotNil(t, err, d.String())
+	}
+}
+
+func TestResampleGapsUnit(t *testing.T) {
+	start := time.Date(2023, 11, 27, 14, 30, 0, 0, time.UTC)
+	bars := append(minuteBars(start, 1), minuteBars(start.Add(46*time.Minute), 1)...)
+
+	resampled, err := Resampler{Duration: 15 * time.Minute, FillGaps: true}.Resample(bars)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{
+		start,
+		start.Add(15 * time.Minute),
+		start.Add(30 * time.Minute),
+		start.Add(45 * time.Minute),
+	}, barTimes(resampled))
+
+	gap := resampled[1]
+	assert.Equal(t, Bar{Time: gap.Time, Open: 100.5, High: 100.5, Low: 100.5, Close: 100.5, VWAP: 100.5}, gap)
+}
+
+func TestResampleSessionsUnit(t *testing.T) {
+	schedule := loadTestSchedule(t)
+	loc, err := schedule.Location()
+	assert.Nil(t, err)
+
+	// 09:00 to 10:04 New York
+	bars := minuteBars(time.Date(2023, 11, 27, 9, 0, 0, 0, loc), 65)
+
+	rth, err := Resampler{Duration: 20 * time.Minute, Schedule: schedule}.Resample(bars)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2023, 11, 27, 9, 30, 0, 0, loc),
+		time.Date(2023, 11, 27, 9, 50, 0, 0, loc),
+	}, barTimes(rth))
+	assert.Equal(t, 130.0, rth[0].Open)
+	assert.Equal(t, 150.0, rth[1].Volume)
+
+	eth, err := Resampler{Duration: 20 * time.Minute, Schedule: schedule, OutsideRth: true}.Resample(bars)
+	assert.Nil(t, err)
+	assert.Equal(t, time.Date(2023, 11, 27, 9, 0, 0, 0, loc), eth[0].Time)
+	assert.Len(t, eth, 4)
+}
+
+func TestResampleDailyUnit(t *testing.T) {
+	schedule := loadTestSchedule(t)
+	loc, err := schedule.Location()
+	assert.Nil(t, err)
+
+	// Tuesday 21 to Monday 27 November, the market is closed on Thanksgiving and the weekend
+	var bars []Bar
+	for _, d := range []int{21, 22, 24, 27} {
+		bars = append(bars, minuteBars(time.Date(2023, 11, d, 10, 0, 0, 0, loc), 2)...)
+	}
+
+	daily, err := Resampler{Duration: 24 * time.Hour, Schedule: schedule, FillGaps: true}.Resample(bars)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2023, 11, 21, 0, 0, 0, 0, loc),
+		time.Date(2023, 11, 22, 0, 0, 0, 0, loc),
+		time.Date(2023, 11, 24, 0, 0, 0, 0, loc),
+		time.Date(2023, 11, 27, 0, 0, 0, 0, loc),
+	}, barTimes(daily))
+
+	weekly, err := Resampler{Duration: 7 * 24 * time.Hour, Location: loc}.Resample(bars)
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Time{
+		time.Date(2023, 11, 20, 0, 0, 0, 0, loc),
+		time.Date(2023, 11, 27, 0, 0, 0, 0, loc),
+	}, barTimes(weekly))
+	assert.Equal(t, 60.0, weekly[0].Volume)
+}
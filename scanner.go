@@ -0,0 +1,375 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	scannerParamsPath = "v1/api/iserver/scanner/params"
+	scannerRunPath    = "v1/api/iserver/scanner/run"
+	hmdsScannerPath   = "v1/api/hmds/scanner"
+)
+
+/*
+ScannerParams - Instruments, locations, scan codes and filters available to scanners
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Scanner/paths/~1iserver~1scanner~1params/get
+*/
+type ScannerParams struct {
+	ScanTypeList   []ScanType          `json:"scan_type_list"`
+	InstrumentList []ScannerInstrument `json:"instrument_list"`
+	FilterList     []ScannerFilterType `json:"filter_list"`
+	LocationTree   []ScannerLocation   `json:"location_tree"`
+}
+
+// ScanType - Scan code and the instruments it can scan
+type ScanType struct {
+	DisplayName string   `json:"display_name"`
+	Code        string   `json:"code"`
+	Instruments []string `json:"instruments"`
+}
+
+// ScannerInstrument - Instrument and the filter codes it supports
+type ScannerInstrument struct {
+	DisplayName string   `json:"display_name"`
+	Type        string   `json:"type"`
+	Filters     []string `json:"filters"`
+}
+
+// ScannerFilterType - Filter available to scanners
+type ScannerFilterType struct {
+	Group       string `json:"group"`
+	DisplayName string `json:"display_name"`
+	Code        string `json:"code"`
+	Type        string `json:"type"`
+}
+
+// ScannerLocation - Node of the location tree, top level nodes are instruments
+type ScannerLocation struct {
+	DisplayName string            `json:"display_name"`
+	Type        string            `json:"type"`
+	Locations   []ScannerLocation `json:"locations"`
+}
+
+// ScanType - gets a scan type by code
+func (s ScannerParams) ScanType(code string) (ScanType, bool) {
+	for _, scanType := range s.ScanTypeList {
+		if scanType.Code == code {
+			return scanType, true
+		}
+	}
+
+	return ScanType{}, false
+}
+
+// Instrument - gets an instrument by type
+func (s ScannerParams) Instrument(instrument string) (ScannerInstrument, bool) {
+	for _, i := range s.InstrumentList {
+		if i.Type == instrument {
+			return i, true
+		}
+	}
+
+	return ScannerInstrument{}, false
+}
+
+// Filter - gets a filter by code
+func (s ScannerParams) Filter(code string) (ScannerFilterType, bool) {
+	for _, filter := range s.FilterList {
+		if filter.Code == code {
+			return filter, true
+		}
+	}
+
+	return ScannerFilterType{}, false
+}
+
+// HasLocation - the location is in the tree of the instrument
+func (s ScannerParams) HasLocation(instrument, location string) bool {
+	for _, root := range s.LocationTree {
+		if root.Type == instrument {
+			return root.has(location)
+		}
+	}
+
+	return false
+}
+
+func (s ScannerLocation) has(location string) bool {
+	for _, l := range s.Locations {
+		if l.Type == location || l.has(location) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ScannerFilter - Filter applied to a scan
+type ScannerFilter struct {
+	Code  string      `json:"code"`
+	Value interface{} `json:"value"`
+}
+
+/*
+ScannerRunInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Scanner/paths/~1iserver~1scanner~1run/post
+*/
+type ScannerRunInput struct {
+	Instrument string          `json:"instrument"`
+	Type       string          `json:"type"`
+	Location   string          `json:"location"`
+	Filter     []ScannerFilter `json:"filter"`
+}
+
+/*
+ScanBuilder - Builds a ScannerRunInput validated against the scanner params.
+Errors are collected and returned by Build.
+*/
+type ScanBuilder struct {
+	params *ScannerParams
+	input  ScannerRunInput
+}
+
+// NewScan - returns a ScanBuilder validating against params
+func NewScan(params *ScannerParams) *ScanBuilder {
+	return &ScanBuilder{params: params}
+}
+
+// Instrument - sets the instrument, e.g. STK
+func (b *ScanBuilder) Instrument(instrument string) *ScanBuilder {
+	b.input.Instrument = instrument
+	return b
+}
+
+// Location - sets the location, e.g. STK.US.MAJOR
+func (b *ScanBuilder) Location(location string) *ScanBuilder {
+	b.input.Location = location
+	return b
+}
+
+// ScanCode - sets the scan code, e.g. HOT_BY_VOLUME
+func (b *ScanBuilder) ScanCode(code string) *ScanBuilder {
+	b.input.Type = code
+	return b
+}
+
+// Filter - adds a filter, e.g. priceAbove 5
+func (b *ScanBuilder) Filter(code string, value interface{}) *ScanBuilder {
+	b.input.Filter = append(b.input.Filter, ScannerFilter{Code: code, Value: value})
+	return b
+}
+
+/*
+Build - Validates and returns the scan. The instrument, scan code, location and
+filters must be listed in the params and supported by the instrument.
+*/
+func (b *ScanBuilder) Build() (ScannerRunInput, error) {
+	if b.params == nil {
+		return ScannerRunInput{}, errors.New("no scanner params to validate the scan against")
+	}
+
+	var problems []string
+
+	instrument, ok := b.params.Instrument(b.input.Instrument)
+	if !ok {
+		problems = append(problems, "unknown instrument '"+b.input.Instrument+"'")
+	}
+
+	if scanType, ok := b.params.ScanType(b.input.Type); !ok {
+		problems = append(problems, "unknown scan code '"+b.input.Type+"'")
+	} else if instrument.Type != "" && !containsString(scanType.Instruments, instrument.Type) {
+		problems = append(problems, "scan code '"+b.input.Type+"' does not support instrument '"+instrument.Type+"'")
+	}
+
+	if !b.params.HasLocation(b.input.Instrument, b.input.Location) {
+		problems = append(problems, "unknown location '"+b.input.Location+"' for instrument '"+b.input.Instrument+"'")
+	}
+
+	for _, filter := range b.input.Filter {
+		if _, ok := b.params.Filter(filter.Code); !ok {
+			problems = append(problems, "unknown filter '"+filter.Code+"'")
+		} else if instrument.Type != "" && !containsString(instrument.Filters, filter.Code) {
+			problems = append(problems, "filter '"+filter.Code+"' is not supported by instrument '"+instrument.Type+"'")
+		}
+	}
+
+	if len(problems) > 0 {
+		return ScannerRunInput{}, errors.Errorf("invalid scan: %s", strings.Join(problems, "; "))
+	}
+
+	return b.input, nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*
+ScannerResult - Contracts matching a scan
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Scanner/paths/~1iserver~1scanner~1run/post
+*/
+type ScannerResult struct {
+	Contracts          []ScannerContract `json:"contracts"`
+	ScanDataColumnName string            `json:"scan_data_column_name"`
+}
+
+// ScannerContract - Contract matching a scan, Conid is usable with SecurityDefinitionInfo and PlaceOrders
+type ScannerContract struct {
+	ServerID              string `json:"server_id"`
+	ColumnName            string `json:"column_name"`
+	Symbol                string `json:"symbol"`
+	Conidex               string `json:"conidex"`
+	Conid                 int    `json:"con_id"`
+	AvailableChartPeriods string `json:"available_chart_periods"`
+	CompanyName           string `json:"company_name"`
+	ScanData              string `json:"scan_data"`
+	ContractDescription1  string `json:"contract_description_1"`
+	ListingExchange       string `json:"listing_exchange"`
+	SecType               string `json:"sec_type"`
+}
+
+// Conids - gets the conids of the contracts in scan order
+func (s ScannerResult) Conids() []int {
+	conids := make([]int, 0, len(s.Contracts))
+	for _, contract := range s.Contracts {
+		conids = append(conids, contract.Conid)
+	}
+
+	return conids
+}
+
+/*
+HMDSScannerInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Scanner/paths/~1hmds~1scanner/post
+*/
+type HMDSScannerInput struct {
+	Instrument string                   `json:"instrument"`
+	Locations  string                   `json:"locations"`
+	ScanCode   string                   `json:"scanCode"`
+	SecType    SecType                  `json:"secType"`
+	MaxItems   int                      `json:"maxItems,omitempty"`
+	Filters    []map[string]interface{} `json:"filters"`
+}
+
+/*
+HMDSScannerResult - Contracts matching an HMDS scan
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Scanner/paths/~1hmds~1scanner/post
+*/
+type HMDSScannerResult struct {
+	Total     int    `json:"total"`
+	Size      int    `json:"size"`
+	Offset    int    `json:"offset"`
+	ScanTime  string `json:"scanTime"`
+	ID        string `json:"id"`
+	Position  string `json:"position"`
+	Contracts struct {
+		Contract []struct {
+			InScanTime string `json:"inScanTime"`
+			ContractID int    `json:"contractID"`
+		} `json:"Contract"`
+	} `json:"Contracts"`
+}
+
+// Conids - gets the conids of the contracts in scan order
+func (h HMDSScannerResult) Conids() []int {
+	conids := make([]int, 0, len(h.Contracts.Contract))
+	for _, contract := range h.Contracts.Contract {
+		conids = append(conids, contract.ContractID)
+	}
+
+	return conids
+}
+
+/*
+ScannerParams - Gets the parameters available to iserver scanners
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Scanner/paths/~1iserver~1scanner~1params/get
+*/
+func (c *client) ScannerParams() (*ScannerParams, error) {
+	resp, err := c.get(scannerParamsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var scannerParams ScannerParams
+	if err := json.Unmarshal(v, &scannerParams); err != nil {
+		return nil, err
+	}
+
+	return &scannerParams, nil
+}
+
+/*
+RunScanner - Runs an iserver scan, use NewScan to build a validated input
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Scanner/paths/~1iserver~1scanner~1run/post
+*/
+func (c *client) RunScanner(input ScannerRunInput) (*ScannerResult, error) {
+	resp, err := c.post(scannerRunPath, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var scannerResult ScannerResult
+	if err := json.Unmarshal(v, &scannerResult); err != nil {
+		return nil, err
+	}
+
+	return &scannerResult, nil
+}
+
+/*
+HMDSScanner - Runs a scan on the historical market data service
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Scanner/paths/~1hmds~1scanner/post
+*/
+func (c *client) HMDSScanner(input HMDSScannerInput) (*HMDSScannerResult, error) {
+	resp, err := c.post(hmdsScannerPath, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var hmdsScannerResult HMDSScannerResult
+	if err := json.Unmarshal(v, &hmdsScannerResult); err != nil {
+		return nil, err
+	}
+
+	return &hmdsScannerResult, nil
+}
@@ -0,0 +1,452 @@
+package ibweb
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScannerIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	params, err := c.ScannerParams()
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	input, err := NewScan(params).
+		Instrument("STK").
+		Location("STK.US.MAJOR").
+		ScanCode("HOT_BY_VOLUME").
+		Filter("priceAbove", 5).
+		Build()
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	result, err := c.RunScanner(input)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Greater(t, len(result.Contracts), 0)
+}
+
+func TestHMDSScannerIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	result, err := c.HMDSScanner(HMDSScannerInput{
+		Instrument: "BOND",
+		Locations:  "BOND.US",
+		ScanCode:   "HIGH_BOND_ASK_YIELD_ALL",
+		SecType:    Bonds,
+		MaxItems:   25,
+	})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	assert.Greater(t, len(result.Conids()), 0)
+}
+
+func TestScannerParamsUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get scanner params")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get scanner params",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read scanner params")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read scanner params",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/scanner_params.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", scannerParamsPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.ScannerParams()
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestRunScannerUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to post",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to post scanner")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to post scanner",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read scanner")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read scanner",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/scanner_run.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", scannerRunPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.RunScanner(ScannerRunInput{})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestHMDSScannerUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to post",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to post hmds scanner")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to post hmds scanner",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read hmds scanner")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read hmds scanner",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/hmds_scanner.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", hmdsScannerPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.HMDSScanner(HMDSScannerInput{})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestScanBuilderUnit(t *testing.T) {
+	v, err := os.ReadFile("testdata/scanner_params.json")
+	assert.Nil(t, err)
+
+	var params ScannerParams
+	assert.Nil(t, json.Unmarshal(v, &params))
+
+	input, err := NewScan(&params).
+		Instrument("STK").
+		Location("STK.NASDAQ").
+		ScanCode("HOT_BY_VOLUME").
+		Filter("priceAbove", 5).
+		Filter("volumeAbove", 100000).
+		Build()
+	assert.Nil(t, err)
+	assert.Equal(t, ScannerRunInput{
+		Instrument: "STK",
+		Type:       "HOT_BY_VOLUME",
+		Location:   "STK.NASDAQ",
+		Filter: []ScannerFilter{
+			{Code: "priceAbove", Value: 5},
+			{Code: "volumeAbove", Value: 100000},
+		},
+	}, input)
+
+	_, err = NewScan(nil).Instrument("STK").Build()
+	assertError(t, true, "no scanner params", err)
+
+	tests := []struct {
+		name            string
+		builder         *ScanBuilder
+		wantErrContains string
+	}{
+		{
+			"unknown instrument",
+			NewScan(&params).Instrument("OPT").Location("STK.US.MAJOR").ScanCode("HOT_BY_VOLUME"),
+			"unknown instrument 'OPT'",
+		},
+		{
+			"unknown scan code",
+			NewScan(&params).Instrument("STK").Location("STK.US.MAJOR").ScanCode("MOST_ACTIVE"),
+			"unknown scan code 'MOST_ACTIVE'",
+		},
+		{
+			"scan code not supporting instrument",
+			NewScan(&params).Instrument("FUT.US").Location("FUT.CME").ScanCode("TOP_PERC_GAIN"),
+			"does not support instrument 'FUT.US'",
+		},
+		{
+			"location of another instrument",
+			NewScan(&params).Instrument("STK").Location("FUT.CME").ScanCode("HOT_BY_VOLUME"),
+			"unknown location 'FUT.CME'",
+		},
+		{
+			"unknown filter",
+			NewScan(&params).Instrument("STK").Location("STK.US.MAJOR").ScanCode("HOT_BY_VOLUME").Filter("changeAbove", 1),
+			"unknown filter 'changeAbove'",
+		},
+		{
+			"filter not supporting instrument",
+			NewScan(&params).Instrument("FUT.US").Location("FUT.CME").ScanCode("HOT_BY_VOLUME").Filter("volumeAbove", 1),
+			"filter 'volumeAbove' is not supported",
+		},
+	}
+
+	for _, tc := range tests {
+		_, err := tc.builder.Build()
+		assertError(t, true, tc.wantErrContains, err)
+	}
+}
+
+func TestScannerConidsUnit(t *testing.T) {
+	v, err := os.ReadFile("testdata/scanner_run.json")
+	assert.Nil(t, err)
+
+	var result ScannerResult
+	assert.Nil(t, json.Unmarshal(v, &result))
+	assert.Equal(t, []int{76792991, 265598}, result.Conids())
+
+	v, err = os.ReadFile("testdata/hmds_scanner.json")
+	assert.Nil(t, err)
+
+	var hmds HMDSScannerResult
+	assert.Nil(t, json.Unmarshal(v, &hmds))
+	assert.Equal(t, []int{431424315, 490221471}, hmds.Conids())
+}
@@ -0,0 +1,357 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	tradingSchedulePath = "v1/api/trsrv/secdef/schedule"
+
+	scheduleDateLayout = "20060102"
+	scheduleTimeLayout = "1504"
+
+	// maxScheduleLookahead - number of days NextOpen searches before giving up
+	maxScheduleLookahead = 31
+)
+
+/*
+TradingScheduleInput -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1secdef~1schedule/get
+*/
+type TradingScheduleInput struct {
+	AssetClass     SecType
+	Symbol         string
+	Exchange       string
+	ExchangeFilter string
+}
+
+func (t TradingScheduleInput) toQuery() []query {
+	queries := []query{
+		{
+			key:   "assetClass",
+			value: string(t.AssetClass),
+		},
+		{
+			key:   "symbol",
+			value: t.Symbol,
+		},
+	}
+
+	if t.Exchange != "" {
+		queries = append(queries, query{
+			key:   "exchange",
+			value: t.Exchange,
+		})
+	}
+
+	if t.ExchangeFilter != "" {
+		queries = append(queries, query{
+			key:   "exchangeFilter",
+			value: t.ExchangeFilter,
+		})
+	}
+
+	return queries
+}
+
+/*
+TradingSchedule - Trading schedule of a venue, times are local to Timezone
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1secdef~1schedule/get
+*/
+type TradingSchedule struct {
+	ID           string               `json:"id"`
+	TradeVenueID string               `json:"tradeVenueId"`
+	Exchange     string               `json:"exchange"`
+	Description  string               `json:"description"`
+	Timezone     string               `json:"timezone"`
+	Schedules    []TradingScheduleDay `json:"schedules"`
+}
+
+// TradingScheduleDay - Sessions of a single date. Dates in the year 2000 are
+// recurring weekday templates, any other date is a specific override.
+type TradingScheduleDay struct {
+	ClearingCycleEndTime string                `json:"clearingCycleEndTime"`
+	TradingScheduleDate  string                `json:"tradingScheduleDate"`
+	Sessions             []TradingScheduleTime `json:"sessions"`
+	TradingTimes         []TradingScheduleTime `json:"tradingtimes"`
+}
+
+// TradingScheduleTime - Opening and closing time formatted as HHMM
+type TradingScheduleTime struct {
+	OpeningTime     string `json:"openingTime"`
+	ClosingTime     string `json:"closingTime"`
+	Prop            string `json:"prop"`
+	CancelDayOrders string `json:"cancelDayOrders"`
+}
+
+// Session - Time range a contract trades in
+type Session struct {
+	Open  time.Time
+	Close time.Time
+}
+
+// Contains - reports if t is within the session
+func (s Session) Contains(t time.Time) bool {
+	return !t.Before(s.Open) && t.Before(s.Close)
+}
+
+// Location - loads the exchange time zone of the schedule
+func (t TradingSchedule) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(t.Timezone)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to load time zone '%s'", t.Timezone)
+	}
+
+	return loc, nil
+}
+
+/*
+SessionsOn - Gets the sessions starting on the date of day in the exchange
+time zone. Regular trading hours are returned unless outsideRTH is set, in which
+case the extended sessions are returned.
+*/
+func (t TradingSchedule) SessionsOn(day time.Time, outsideRTH bool) ([]Session, error) {
+	loc, err := t.Location()
+	if err != nil {
+		return nil, err
+	}
+
+	return t.sessionsOn(day.In(loc), outsideRTH)
+}
+
+func (t TradingSchedule) sessionsOn(day time.Time, outsideRTH bool) ([]Session, error) {
+	scheduleDay, ok := t.scheduleFor(day)
+	if !ok {
+		return nil, nil
+	}
+
+	times := scheduleDay.TradingTimes
+	if outsideRTH || len(times) == 0 {
+		if len(scheduleDay.Sessions) > 0 {
+			times = scheduleDay.Sessions
+		}
+	}
+
+	sessions := make([]Session, 0, len(times))
+	for _, tt := range times {
+		opening, err := scheduleClock(day, tt.OpeningTime)
+		if err != nil {
+			return nil, err
+		}
+
+		closing, err := scheduleClock(day, tt.ClosingTime)
+		if err != nil {
+			return nil, err
+		}
+
+		if !closing.After(opening) {
+			closing = closing.AddDate(0, 0, 1)
+		}
+
+		sessions = append(sessions, Session{Open: opening, Close: closing})
+	}
+
+	return sessions, nil
+}
+
+func (t TradingSchedule) scheduleFor(day time.Time) (TradingScheduleDay, bool) {
+	date := day.Format(scheduleDateLayout)
+	for _, s := range t.Schedules {
+		if s.TradingScheduleDate == date {
+			return s, true
+		}
+	}
+
+	for _, s := range t.Schedules {
+		d, err := time.Parse(scheduleDateLayout, s.TradingScheduleDate)
+		if err != nil || d.Year() != 2000 {
+			continue
+		}
+
+		if d.Weekday() == day.Weekday() {
+			return s, true
+		}
+	}
+
+	return TradingScheduleDay{}, false
+}
+
+func scheduleClock(day time.Time, clock string) (time.Time, error) {
+	if clock == "2400" {
+		return time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, day.Location()), nil
+	}
+
+	c, err := time.Parse(scheduleTimeLayout, clock)
+	if err != nil {
+		return time.Time{}, errors.Wrapf(err, "invalid schedule time '%s'", clock)
+	}
+
+	return time.Date(day.Year(), day.Month(), day.Day(), c.Hour(), c.Minute(), 0, 0, day.Location()), nil
+}
+
+/*
+IsOpen - reports if the schedule has a session containing at
+*/
+func (t TradingSchedule) IsOpen(at time.Time, outsideRTH bool) (bool, error) {
+	loc, err := t.Location()
+	if err != nil {
+		return false, err
+	}
+
+	local := at.In(loc)
+	// sessions starting the day before may run past midnight
+	for _, day := range []time.Time{local.AddDate(0, 0, -1), local} {
+		sessions, err := t.sessionsOn(day, outsideRTH)
+		if err != nil {
+			return false, err
+		}
+
+		for _, s := range sessions {
+			if s.Contains(local) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+/*
+NextOpen - Gets the time the next session opens at or after at. If a session is
+in progress at is returned.
+*/
+func (t TradingSchedule) NextOpen(at time.Time, outsideRTH bool) (time.Time, error) {
+	open, err := t.IsOpen(at, outsideRTH)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if open {
+		return at, nil
+	}
+
+	loc, err := t.Location()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	local := at.In(loc)
+	for i := 0; i <= maxScheduleLookahead; i++ {
+		sessions, err := t.sessionsOn(local.AddDate(0, 0, i), outsideRTH)
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		for _, s := range sessions {
+			if !s.Open.Before(local) {
+				return s.Open, nil
+			}
+		}
+	}
+
+	return time.Time{}, errors.Errorf("no session opens within %d days of %s", maxScheduleLookahead, at)
+}
+
+/*
+Calendar - Trading schedules by contract ID. Pass Order.OutsideRTH as
+outsideRTH to check the hours an order can execute in.
+*/
+type Calendar struct {
+	mu        sync.RWMutex
+	schedules map[int]TradingSchedule
+}
+
+// NewCalendar - returns an empty Calendar
+func NewCalendar() *Calendar {
+	return &Calendar{
+		schedules: map[int]TradingSchedule{},
+	}
+}
+
+// Set - sets the trading schedule of a contract
+func (c *Calendar) Set(conid int, schedule TradingSchedule) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.schedules[conid] = schedule
+}
+
+// Schedule - gets the trading schedule of a contract
+func (c *Calendar) Schedule(conid int) (TradingSchedule, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	s, ok := c.schedules[conid]
+	return s, ok
+}
+
+/*
+Load - Gets the trading schedule through the client and sets it for the contract.
+The first venue returned is used.
+*/
+func (c *Calendar) Load(cl Client, conid int, input TradingScheduleInput) error {
+	schedules, err := cl.TradingSchedule(input)
+	if err != nil {
+		return err
+	}
+
+	if len(schedules) == 0 {
+		return errors.Errorf("no trading schedule found for '%s'", input.Symbol)
+	}
+
+	c.Set(conid, schedules[0])
+	return nil
+}
+
+// IsOpen - reports if the contract trades at t
+func (c *Calendar) IsOpen(conid int, t time.Time, outsideRTH bool) (bool, error) {
+	s, ok := c.Schedule(conid)
+	if !ok {
+		return false, errors.Errorf("no trading schedule for conid '%d'", conid)
+	}
+
+	return s.IsOpen(t, outsideRTH)
+}
+
+// NextOpen - gets the time the contract next trades at or after t
+func (c *Calendar) NextOpen(conid int, t time.Time, outsideRTH bool) (time.Time, error) {
+	s, ok := c.Schedule(conid)
+	if !ok {
+		return time.Time{}, errors.Errorf("no trading schedule for conid '%d'", conid)
+	}
+
+	return s.NextOpen(t, outsideRTH)
+}
+
+/*
+TradingSchedule - Gets the trading schedule of a symbol
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Contract/paths/~1trsrv~1secdef~1schedule/get
+*/
+func (c *client) TradingSchedule(input TradingScheduleInput) ([]TradingSchedule, error) {
+	resp, err := c.get(tradingSchedulePath, input.toQuery()...)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var tradingSchedules []TradingSchedule
+	if err := json.Unmarshal(v, &tradingSchedules); err != nil {
+		return nil, err
+	}
+
+	return tradingSchedules, nil
+}
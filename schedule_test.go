@@ -0,0 +1,221 @@
+package ibweb
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTradingScheduleIntegration(t *testing.T) {
+	c := NewWithClient(&http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}}, "https://127.0.0.1:5555")
+
+	schedules, err := c.TradingSchedule(TradingScheduleInput{
+		AssetClass: Stock,
+		Symbol:     "AAPL",
+	})
+	assert.Nil(t, err)
+	assert.Greater(t, len(schedules), 0)
+}
+
+func TestTradingScheduleUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get trading schedule")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get trading schedule",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read trading schedule")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read trading schedule",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/trading_schedule.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", tradingSchedulePath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.TradingSchedule(TradingScheduleInput{})
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestCalendarUnit(t *testing.T) {
+	v, err := os.ReadFile("./testdata/trading_schedule.json")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	var schedules []TradingSchedule
+	if !assert.Nil(t, json.Unmarshal(v, &schedules)) {
+		t.FailNow()
+	}
+
+	ny, err := time.LoadLocation("America/New_York")
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	cal := NewCalendar()
+	cal.Set(265598, schedules[0])
+
+	tests := []struct {
+		name         string
+		at           time.Time
+		outsideRTH   bool
+		wantOpen     bool
+		wantNextOpen time.Time
+	}{
+		{
+			"open during regular hours",
+			time.Date(2023, 11, 27, 10, 0, 0, 0, ny),
+			false,
+			true,
+			time.Date(2023, 11, 27, 10, 0, 0, 0, ny),
+		},
+		{
+			"closed pre market without outside rth",
+			time.Date(2023, 11, 27, 8, 0, 0, 0, ny),
+			false,
+			false,
+			time.Date(2023, 11, 27, 9, 30, 0, 0, ny),
+		},
+		{
+			"open pre market with outside rth",
+			time.Date(2023, 11, 27, 8, 0, 0, 0, ny),
+			true,
+			true,
+			time.Date(2023, 11, 27, 8, 0, 0, 0, ny),
+		},
+		{
+			"closed over the weekend",
+			time.Date(2023, 11, 25, 12, 0, 0, 0, ny),
+			false,
+			false,
+			time.Date(2023, 11, 27, 9, 30, 0, 0, ny),
+		},
+		{
+			"closed on holiday",
+			time.Date(2023, 11, 23, 12, 0, 0, 0, ny),
+			false,
+			false,
+			time.Date(2023, 11, 24, 9, 30, 0, 0, ny),
+		},
+		{
+			"closed after early close",
+			time.Date(2023, 11, 24, 13, 30, 0, 0, ny),
+			false,
+			false,
+			time.Date(2023, 11, 27, 9, 30, 0, 0, ny),
+		},
+		{
+			"converts to exchange time zone",
+			time.Date(2023, 11, 27, 15, 0, 0, 0, time.UTC),
+			false,
+			true,
+			time.Date(2023, 11, 27, 15, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tc := range tests {
+		open, err := cal.IsOpen(265598, tc.at, tc.outsideRTH)
+		assert.Nil(t, err, tc.name)
+		assert.Equal(t, tc.wantOpen, open, tc.name)
+
+		next, err := cal.NextOpen(265598, tc.at, tc.outsideRTH)
+		assert.Nil(t, err, tc.name)
+		assert.True(t, tc.wantNextOpen.Equal(next), "%s: got %s", tc.name, next)
+	}
+
+	_, err = cal.IsOpen(1, time.Now(), false)
+	assertError(t, true, "no trading schedule", err)
+}
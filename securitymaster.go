@@ -0,0 +1,287 @@
+package ibweb
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// SecurityMasterEntry - A contract stored in the SecurityMaster
+type SecurityMasterEntry struct {
+	Conid           int       `json:"conid"`
+	Ticker          string    `json:"ticker"`
+	Exchange        string    `json:"exchange"`
+	SecType         SecType   `json:"secType"`
+	ListingExchange string    `json:"listingExchange,omitempty"`
+	Currency        string    `json:"currency,omitempty"`
+	Cusip           string    `json:"cusip,omitempty"`
+	Description     string    `json:"description,omitempty"`
+	TradingClass    string    `json:"tradingClass,omitempty"`
+	ValidExchanges  string    `json:"validExchanges,omitempty"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+/*
+SecurityMaster - Local store of contracts populated from AllConids and
+SecurityDefinitionInfo, persisted to a file as JSON lines.
+*/
+type SecurityMaster struct {
+	// MaxAge - entries older than MaxAge are refetched on Refresh, zero never refetches
+	MaxAge time.Duration
+
+	mu      sync.RWMutex
+	path    string
+	entries map[int]SecurityMasterEntry
+}
+
+/*
+NewSecurityMaster - returns a SecurityMaster persisted at path, loading any
+entries already saved there.
+*/
+func NewSecurityMaster(path string) (*SecurityMaster, error) {
+	s := &SecurityMaster{
+		path:    path,
+		entries: map[int]SecurityMasterEntry{},
+	}
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(strings.TrimSpace(scanner.Text())) == 0 {
+			continue
+		}
+
+		var entry SecurityMasterEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, errors.Wrapf(err, "failed to read security master line %d", line)
+		}
+
+		s.entries[entry.Conid] = entry
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// securityMasterPacingInterval - wait between security definition requests of a refresh
+var securityMasterPacingInterval = 100 * time.Millisecond
+
+/*
+Refresh - Gets every contract listed on exchange and fetches the security
+definition of contracts that are new or older than MaxAge, one request every
+securityMasterPacingInterval. Contracts no longer listed on the exchange are
+removed. Contracts without a matching security definition are kept without
+updating their fetch time so they are retried. The store is saved once refreshed, or with the contracts fetched so far
+when a request fails so the next Refresh resumes from there.
+*/
+func (s *SecurityMaster) Refresh(c Client, exchange string, assetClass SecType) error {
+	if assetClass == "" {
+		assetClass = Stock
+	}
+
+	listed, err := c.AllConids(AllConidsInput{Exchange: exchange, AssetClass: assetClass})
+	if err != nil {
+		return err
+	}
+
+	now := nowFn()
+	current := map[int]bool{}
+	requests := 0
+	for _, l := range listed {
+		current[l.Conid] = true
+
+		existing, ok := s.ByConid(l.Conid)
+		if ok && existing.Ticker == l.Ticker && !existing.UpdatedAt.IsZero() && (s.MaxAge == 0 || now.Sub(existing.UpdatedAt) < s.MaxAge) {
+			continue
+		}
+
+		entry := SecurityMasterEntry{
+			Conid:    l.Conid,
+			Ticker:   l.Ticker,
+			Exchange: l.Exchange,
+			SecType:  assetClass,
+		}
+
+		if requests > 0 {
+			sleepFn(securityMasterPacingInterval)
+		}
+		requests++
+
+		infos, err := c.SecurityDefinitionInfo(SecurityDefinitionInfoInput{
+			ConID:   strconv.Itoa(l.Conid),
+			SecType: assetClass,
+		})
+		if err != nil {
+			err = errors.Wrapf(err, "failed to get security definition of conid '%d'", l.Conid)
+			if saveErr := s.Save(); saveErr != nil {
+				return errors.Wrapf(saveErr, "%s, failed to save progress", err)
+			}
+
+			return err
+		}
+
+		matched := false
+		for _, info := range infos {
+			if info.Conid != l.Conid {
+				continue
+			}
+
+			matched = true
+
+			entry.ListingExchange = info.ListingExchange
+			entry.Currency = info.Currency
+			entry.Cusip = info.Cusip
+			entry.Description = strings.TrimSpace(info.Desc1 + " " + info.Desc2)
+			entry.TradingClass = info.TradingClass
+			entry.ValidExchanges = info.ValidExchanges
+			break
+		}
+
+		// without a matching definition the entry keeps its previous fetch time, or none, so it is retried
+		if !matched {
+			if !ok || existing.Ticker != l.Ticker {
+				s.Put(entry)
+			}
+			continue
+		}
+
+		entry.UpdatedAt = now
+		s.Put(entry)
+	}
+
+	s.mu.Lock()
+	for conid, entry := range s.entries {
+		if entry.Exchange == exchange && entry.SecType == assetClass && !current[conid] {
+			delete(s.entries, conid)
+		}
+	}
+	s.mu.Unlock()
+
+	return s.Save()
+}
+
+// Put - adds or replaces an entry
+func (s *SecurityMaster) Put(entry SecurityMasterEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries[entry.Conid] = entry
+}
+
+// ByConid - gets the entry of a conid
+func (s *SecurityMaster) ByConid(conid int) (SecurityMasterEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[conid]
+	return entry, ok
+}
+
+// BySymbol - gets the entries with a ticker, case insensitive
+func (s *SecurityMaster) BySymbol(symbol string) []SecurityMasterEntry {
+	return s.Find(func(e SecurityMasterEntry) bool {
+		return strings.EqualFold(e.Ticker, symbol)
+	})
+}
+
+// ByCusip - gets the entries with a CUSIP, case insensitive
+func (s *SecurityMaster) ByCusip(cusip string) []SecurityMasterEntry {
+	return s.Find(func(e SecurityMasterEntry) bool {
+		return e.Cusip != "" && strings.EqualFold(e.Cusip, cusip)
+	})
+}
+
+// Find - gets the entries matching fn ordered by conid
+func (s *SecurityMaster) Find(fn func(e SecurityMasterEntry) bool) []SecurityMasterEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var found []SecurityMasterEntry
+	for _, entry := range s.entries {
+		if fn(entry) {
+			found = append(found, entry)
+		}
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].Conid < found[j].Conid })
+	return found
+}
+
+// Len - number of entries stored
+func (s *SecurityMaster) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.entries)
+}
+
+/*
+Save - Writes every entry as JSON lines ordered by conid. The file is replaced
+atomically so a failed save leaves the previous contents intact.
+*/
+func (s *SecurityMaster) Save() error {
+	entries := s.Find(func(SecurityMasterEntry) bool { return true })
+
+	return writeFileAtomic(s.path, func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// writeFileAtomic - writes path through a temporary file renamed once written
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+	if err := write(w); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// CreateTemp creates the file readable only by the owner
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,220 @@
+package ibweb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityMasterUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	now := time.Date(2023, 11, 27, 0, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return now }
+	defer func() { nowFn = time.Now }()
+
+	listed := []ExchangeConid{
+		{Ticker: "AAPL", Conid: 265598, Exchange: "NASDAQ"},
+		{Ticker: "MSFT", Conid: 272093, Exchange: "NASDAQ"},
+	}
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", allConidsPath),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewJsonResponse(200, listed)
+		})
+
+	infoURL := fmt.Sprintf("http://127.0.0.1:5555/%s", secDefInfoPath)
+	httpmock.RegisterResponder(http.MethodGet, infoURL,
+		func(req *http.Request) (*http.Response, error) {
+			conid := req.URL.Query().Get("conid")
+			return httpmock.NewStringResponse(200, fmt.Sprintf(
+				`[{"conid":%s,"symbol":"X","secType":"STK","listingExchange":"NASDAQ","currency":"USD","cusip":"CUSIP%s","desc1":"X","desc2":"NASDAQ"}]`,
+				conid, conid,
+			)), nil
+		})
+	infoCalls := func() int {
+		return httpmock.GetCallCountInfo()[http.MethodGet+" "+infoURL]
+	}
+
+	path := filepath.Join(t.TempDir(), "secmaster.jsonl")
+	c := New("http://127.0.0.1:5555")
+
+	master, err := NewSecurityMaster(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	master.MaxAge = 24 * time.Hour
+
+	if !assert.Nil(t, master.Refresh(c, "NASDAQ", Stock)) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, infoCalls())
+	assert.Equal(t, 2, master.Len())
+
+	aapl := master.BySymbol("aapl")
+	if assert.Len(t, aapl, 1) {
+		assert.Equal(t, 265598, aapl[0].Conid)
+		assert.Equal(t, "USD", aapl[0].Currency)
+		assert.Equal(t, "X NASDAQ", aapl[0].Description)
+	}
+	assert.Len(t, master.ByCusip("CUSIP272093"), 1)
+
+	// unchanged contracts are not refetched
+	listed = append(listed, ExchangeConid{Ticker: "AMZN", Conid: 3691937, Exchange: "NASDAQ"})
+	if !assert.Nil(t, master.Refresh(c, "NASDAQ", Stock)) {
+		t.FailNow()
+	}
+	assert.Equal(t, 3, infoCalls())
+
+	// stale contracts are refetched and delisted contracts removed
+	now = now.Add(48 * time.Hour)
+	listed = listed[:1]
+	if !assert.Nil(t, master.Refresh(c, "NASDAQ", Stock)) {
+		t.FailNow()
+	}
+	assert.Equal(t, 4, infoCalls())
+	assert.Equal(t, 1, master.Len())
+
+	reloaded, err := NewSecurityMaster(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	entry, ok := reloaded.ByConid(265598)
+	assert.True(t, ok)
+	assert.Equal(t, "CUSIP265598", entry.Cusip)
+	assert.True(t, now.Equal(entry.UpdatedAt))
+
+	info, err := os.Stat(path)
+	if assert.Nil(t, err) {
+		assert.Equal(t, os.FileMode(0o644), info.Mode().Perm())
+	}
+
+	if !assert.Nil(t, os.WriteFile(path, []byte("garbage\n"), 0o600)) {
+		t.FailNow()
+	}
+	_, err = NewSecurityMaster(path)
+	assertError(t, true, "failed to read security master line 1", err)
+}
+
+func TestSecurityMasterPartialRefreshUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	var slept []time.Duration
+	sleepFn = func(d time.Duration) { slept = append(slept, d) }
+	defer func() { sleepFn = time.Sleep }()
+
+	listed := []ExchangeConid{
+		{Ticker: "AAPL", Conid: 265598, Exchange: "NASDAQ"},
+		{Ticker: "MSFT", Conid: 272093, Exchange: "NASDAQ"},
+		{Ticker: "AMZN", Conid: 3691937, Exchange: "NASDAQ"},
+	}
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", allConidsPath),
+		httpmock.NewJsonResponderOrPanic(200, listed))
+
+	failing := "3691937"
+	var requested []string
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", secDefInfoPath),
+		func(req *http.Request) (*http.Response, error) {
+			conid := req.URL.Query().Get("conid")
+			requested = append(requested, conid)
+			if conid == failing {
+				return httpmock.NewStringResponse(429, "too many requests"), nil
+			}
+
+			return httpmock.NewStringResponse(200, fmt.Sprintf(`[{"conid":%s,"currency":"USD"}]`, conid)), nil
+		})
+
+	path := filepath.Join(t.TempDir(), "secmaster.jsonl")
+	c := New("http://127.0.0.1:5555")
+
+	master, err := NewSecurityMaster(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	err = master.Refresh(c, "NASDAQ", Stock)
+	assertError(t, true, "failed to get security definition of conid '3691937'", err)
+	assert.Equal(t, []time.Duration{securityMasterPacingInterval, securityMasterPacingInterval}, slept)
+
+	// contracts fetched before the failure are saved
+	reloaded, err := NewSecurityMaster(path)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, reloaded.Len())
+
+	// the next refresh only fetches the remaining contract
+	failing = ""
+	requested = nil
+	if !assert.Nil(t, reloaded.Refresh(c, "NASDAQ", Stock)) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"3691937"}, requested)
+	assert.Equal(t, 3, reloaded.Len())
+}
+
+func TestSecurityMasterUnmatchedRefreshUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	now := time.Date(2023, 11, 27, 0, 0, 0, 0, time.UTC)
+	nowFn = func() time.Time { return now }
+	defer func() { nowFn = time.Now }()
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", allConidsPath),
+		httpmock.NewJsonResponderOrPanic(200, []ExchangeConid{{Ticker: "AAPL", Conid: 265598, Exchange: "NASDAQ"}}))
+
+	body := `[{"conid":1,"currency":"USD"}]`
+	requests := 0
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", secDefInfoPath),
+		func(req *http.Request) (*http.Response, error) {
+			requests++
+			return httpmock.NewStringResponse(200, body), nil
+		})
+
+	c := New("http://127.0.0.1:5555")
+	master, err := NewSecurityMaster(filepath.Join(t.TempDir(), "secmaster.jsonl"))
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+
+	// the contract is listed without a fetch time when its definition is missing
+	if !assert.Nil(t, master.Refresh(c, "NASDAQ", Stock)) {
+		t.FailNow()
+	}
+	entry, ok := master.ByConid(265598)
+	assert.True(t, ok)
+	assert.True(t, entry.UpdatedAt.IsZero())
+
+	// and retried on the next refresh
+	body = `[{"conid":265598,"currency":"USD"}]`
+	if !assert.Nil(t, master.Refresh(c, "NASDAQ", Stock)) {
+		t.FailNow()
+	}
+	assert.Equal(t, 2, requests)
+	entry, _ = master.ByConid(265598)
+	assert.Equal(t, "USD", entry.Currency)
+	assert.True(t, now.Equal(entry.UpdatedAt))
+
+	// a stale entry keeps its data and fetch time when the definition is missing
+	now = now.Add(time.Hour)
+	master.MaxAge = time.Minute
+	body = `[]`
+	if !assert.Nil(t, master.Refresh(c, "NASDAQ", Stock)) {
+		t.FailNow()
+	}
+	assert.Equal(t, 3, requests)
+	stale, _ := master.ByConid(265598)
+	assert.Equal(t, entry, stale)
+}
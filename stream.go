@@ -0,0 +1,479 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+const (
+	streamPath       = "v1/api/ws"
+	marketDataTopic  = "smd"
+	unsubscribeTopic = "umd"
+	heartbeatMessage = "tic"
+)
+
+var (
+	// streamHeartbeatInterval - interval heartbeats are sent to keep the session alive
+	streamHeartbeatInterval = 30 * time.Second
+	// streamReconnectInterval - initial wait before reconnecting, doubled per failed attempt
+	streamReconnectInterval = time.Second
+	// streamMaxReconnectInterval - longest wait between reconnect attempts
+	streamMaxReconnectInterval = 30 * time.Second
+	// streamBufferSize - updates buffered per subscription before the oldest is dropped
+	streamBufferSize = 64
+)
+
+/*
+Stream - Client Portal websocket connection. Subscriptions are kept across
+reconnects and resubscribed automatically.
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Websocket
+*/
+type Stream struct {
+	url    string
+	dialer *websocket.Dialer
+	header http.Header
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	subs    map[string]*streamSubscription
+	closed  bool
+	done    chan struct{}
+	errs    chan error
+	writeMu sync.Mutex
+}
+
+// streamSubscription - topic routed to a handler, resubscribed on reconnect
+type streamSubscription struct {
+	topic string
+	// route - topic of the updates when it differs from topic, may be shared by several subscriptions
+	route       string
+	subscribe   string
+	unsubscribe string
+	handle      func(msg []byte)
+	reset       func()
+	close       func()
+}
+
+/*
+NewStream - returns a Stream for the websocket at url, e.g.
+wss://localhost:5000/v1/api/ws. The header is sent when dialing and should carry
+the session cookie, a nil dialer uses websocket.DefaultDialer.
+*/
+func NewStream(url string, dialer *websocket.Dialer, header http.Header) *Stream {
+	if dialer == nil {
+		dialer = websocket.DefaultDialer
+	}
+
+	return &Stream{
+		url:    url,
+		dialer: dialer,
+		header: header,
+		subs:   map[string]*streamSubscription{},
+		done:   make(chan struct{}),
+		errs:   make(chan error, 16),
+	}
+}
+
+// StreamURL - returns the websocket URL of a Client Portal URL such as https://127.0.0.1:5555
+func StreamURL(url string) string {
+	switch {
+	case strings.HasPrefix(url, "https://"):
+		url = "wss://" + strings.TrimPrefix(url, "https://")
+	case strings.HasPrefix(url, "http://"):
+		url = "ws://" + strings.TrimPrefix(url, "http://")
+	}
+
+	return fmt.Sprintf("%s/%s", strings.TrimSuffix(url, "/"), streamPath)
+}
+
+// Errors - connection errors, dropped when not received
+func (s *Stream) Errors() <-chan error {
+	return s.errs
+}
+
+// Connect - dials the websocket and starts reading, reconnecting until Close is called
+func (s *Stream) Connect() error {
+	conn, err := s.dial()
+	if err != nil {
+		return err
+	}
+
+	go s.run(conn)
+	return nil
+}
+
+// Close - closes the connection and every subscription
+func (s *Stream) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+
+	s.closed = true
+	close(s.done)
+	conn := s.conn
+	subs := s.subs
+	s.subs = map[string]*streamSubscription{}
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.close()
+	}
+
+	if conn == nil {
+		return nil
+	}
+
+	return conn.Close()
+}
+
+func (s *Stream) dial() (*websocket.Conn, error) {
+	conn, _, err := s.dialer.Dial(s.url, s.header)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dial stream")
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		conn.Close()
+		return nil, errors.New("stream is closed")
+	}
+
+	s.conn = conn
+	subs := make([]*streamSubscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	s.mu.Unlock()
+
+	for _, sub := range subs {
+		if sub.reset != nil {
+			sub.reset()
+		}
+
+		if err := s.write(conn, sub.subscribe); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return conn, nil
+}
+
+func (s *Stream) run(conn *websocket.Conn) {
+	for {
+		stop := make(chan struct{})
+		go s.heartbeat(conn, stop)
+
+		err := s.read(conn)
+		close(stop)
+
+		// subscriptions made while disconnected are sent by the next dial
+		s.mu.Lock()
+		if s.conn == conn {
+			s.conn = nil
+		}
+		s.mu.Unlock()
+		conn.Close()
+
+		if s.isClosed() {
+			return
+		}
+		s.report(err)
+
+		conn = s.reconnect()
+		if conn == nil {
+			return
+		}
+	}
+}
+
+func (s *Stream) reconnect() *websocket.Conn {
+	wait := streamReconnectInterval
+	for {
+		select {
+		case <-s.done:
+			return nil
+		case <-time.After(wait):
+		}
+
+		conn, err := s.dial()
+		if err == nil {
+			return conn
+		}
+
+		if s.isClosed() {
+			return nil
+		}
+		s.report(err)
+
+		wait *= 2
+		if wait > streamMaxReconnectInterval {
+			wait = streamMaxReconnectInterval
+		}
+	}
+}
+
+func (s *Stream) heartbeat(conn *websocket.Conn, stop chan struct{}) {
+	ticker := time.NewTicker(streamHeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := s.write(conn, heartbeatMessage); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Stream) read(conn *websocket.Conn) error {
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			return errors.Wrap(err, "failed to read stream")
+		}
+
+		var envelope struct {
+			Topic string `json:"topic"`
+		}
+		if err := json.Unmarshal(msg, &envelope); err != nil || envelope.Topic == "" {
+			continue
+		}
+
+		for _, sub := range s.routes(envelope.Topic) {
+			sub.handle(msg)
+		}
+	}
+}
+
+// routes - gets the subscriptions receiving the updates of topic
+func (s *Stream) routes(topic string) []*streamSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var subs []*streamSubscription
+	for _, sub := range s.subs {
+		if sub.topic == topic || sub.route == topic {
+			subs = append(subs, sub)
+		}
+	}
+
+	return subs
+}
+
+func (s *Stream) write(conn *websocket.Conn, msg string) error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		return errors.Wrap(err, "failed to write stream")
+	}
+
+	return nil
+}
+
+func (s *Stream) report(err error) {
+	select {
+	case s.errs <- err:
+	default:
+	}
+}
+
+func (s *Stream) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+func (s *Stream) add(sub *streamSubscription) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errors.New("stream is closed")
+	}
+
+	if _, ok := s.subs[sub.topic]; ok {
+		s.mu.Unlock()
+		return errors.Errorf("already subscribed to '%s'", sub.topic)
+	}
+
+	s.subs[sub.topic] = sub
+	conn := s.conn
+	s.mu.Unlock()
+
+	// subscriptions made before connecting are sent once connected
+	if conn == nil {
+		return nil
+	}
+
+	if err := s.write(conn, sub.subscribe); err != nil {
+		s.mu.Lock()
+		delete(s.subs, sub.topic)
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+/*
+remove - unsubscribes from topic. Subscriptions stopped by the same unsubscribe
+message, such as every book of an account, are subscribed again afterwards.
+*/
+func (s *Stream) remove(topic string) error {
+	s.mu.Lock()
+	sub, ok := s.subs[topic]
+	delete(s.subs, topic)
+	conn := s.conn
+
+	var shared []*streamSubscription
+	if ok {
+		for _, other := range s.subs {
+			if other.unsubscribe == sub.unsubscribe {
+				shared = append(shared, other)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	sub.close()
+	if conn == nil {
+		return nil
+	}
+
+	if err := s.write(conn, sub.unsubscribe); err != nil {
+		return err
+	}
+
+	for _, other := range shared {
+		if other.reset != nil {
+			other.reset()
+		}
+
+		if err := s.write(conn, other.subscribe); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+/*
+MarketDataSubscription - Streamed market data of a contract. Updates carry only
+the fields that changed and are delivered on C, the oldest update is dropped
+when C is not drained.
+*/
+type MarketDataSubscription struct {
+	Conid  int
+	Fields []SnapshotField
+	C      <-chan Snapshot
+
+	stream *Stream
+	topic  string
+	mu     sync.Mutex
+	ch     chan Snapshot
+	closed bool
+}
+
+/*
+SubscribeMarketData - Subscribes to streamed market data of a contract
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Websocket
+*/
+func (s *Stream) SubscribeMarketData(conid int, fields []SnapshotField) (*MarketDataSubscription, error) {
+	codes := make([]string, 0, len(fields))
+	for _, field := range fields {
+		codes = append(codes, field.String())
+	}
+
+	args, err := json.Marshal(struct {
+		Fields []string `json:"fields"`
+	}{codes})
+	if err != nil {
+		return nil, err
+	}
+
+	topic := fmt.Sprintf("%s+%d", marketDataTopic, conid)
+	ch := make(chan Snapshot, streamBufferSize)
+	m := &MarketDataSubscription{
+		Conid:  conid,
+		Fields: fields,
+		C:      ch,
+		stream: s,
+		topic:  topic,
+		ch:     ch,
+	}
+
+	if err := s.add(&streamSubscription{
+		topic:       topic,
+		subscribe:   fmt.Sprintf("%s+%s", topic, args),
+		unsubscribe: fmt.Sprintf("%s+%d+{}", unsubscribeTopic, conid),
+		handle:      m.handle,
+		close:       m.close,
+	}); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// Unsubscribe - stops the subscription and closes C
+func (m *MarketDataSubscription) Unsubscribe() error {
+	return m.stream.remove(m.topic)
+}
+
+func (m *MarketDataSubscription) handle(msg []byte) {
+	var update Snapshot
+	if err := json.Unmarshal(msg, &update); err != nil {
+		m.stream.report(errors.Wrapf(err, "failed to decode '%s' update", m.topic))
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.closed {
+		return
+	}
+
+	for {
+		select {
+		case m.ch <- update:
+			return
+		default:
+		}
+
+		// drop the oldest update to make room
+		select {
+		case <-m.ch:
+		default:
+		}
+	}
+}
+
+func (m *MarketDataSubscription) close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.closed {
+		m.closed = true
+		close(m.ch)
+	}
+}
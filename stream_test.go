@@ -0,0 +1,191 @@
+package ibweb
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// testStreamServer - local websocket stand-in recording the commands it receives
+type testStreamServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	conns    []*websocket.Conn
+	received chan string
+}
+
+func newTestStreamServer(t *testing.T) *testStreamServer {
+	s := &testStreamServer{received: make(chan string, 64)}
+	upgrader := websocket.Upgrader{}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("failed to upgrade: %v", err)
+			return
+		}
+
+		s.mu.Lock()
+		s.conns = append(s.conns, conn)
+		s.mu.Unlock()
+
+		for {
+			_, msg, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			s.received <- string(msg)
+		}
+	}))
+
+	return s
+}
+
+func (s *testStreamServer) url() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http")
+}
+
+func (s *testStreamServer) send(t *testing.T, msg string) {
+	s.mu.Lock()
+	conn := s.conns[len(s.conns)-1]
+	s.mu.Unlock()
+
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+}
+
+func (s *testStreamServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.conns[len(s.conns)-1].Close()
+}
+
+func (s *testStreamServer) expect(t *testing.T, want string) {
+	select {
+	case got := <-s.received:
+		assert.Equal(t, want, got)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for '%s'", want)
+	}
+}
+
+func TestStreamURLUnit(t *testing.T) {
+	assert.Equal(t, "wss://127.0.0.1:5555/v1/api/ws", StreamURL("https://127.0.0.1:5555"))
+	assert.Equal(t, "ws://localhost:5000/v1/api/ws", StreamURL("http://localhost:5000/"))
+}
+
+func TestStreamSubscribeMarketDataUnit(t *testing.T) {
+	oldReconnectInterval := streamReconnectInterval
+	streamReconnectInterval = 10 * time.Millisecond
+	defer func() { streamReconnectInterval = oldReconnectInterval }()
+
+	server := newTestStreamServer(t)
+	defer server.Close()
+
+	s := NewStream(server.url(), nil, nil)
+	defer s.Close()
+
+	// subscribed before connecting, sent once connected
+	sub, err := s.SubscribeMarketData(265598, []SnapshotField{FieldLast, FieldBid})
+	assert.Nil(t, err)
+
+	_, err = s.SubscribeMarketData(265598, []SnapshotField{FieldLast})
+	assert.NotNil(t, err)
+
+	assert.Nil(t, s.Connect())
+	server.expect(t, `smd+265598+{"fields":["31","84"]}`)
+
+	server.send(t, `{"topic":"system","success":"user"}`)
+	server.send(t, `{"topic":"smd+265598","conid":265598,"_updated":1702334859712,"31":"193.18","84":"193.17"}`)
+
+	select {
+	case update := <-sub.C:
+		assert.Equal(t, 265598, update.Conid)
+		assert.Equal(t, 193.18, update.Get(FieldLast).Value)
+		assert.Equal(t, 193.17, update.Get(FieldBid).Value)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for update")
+	}
+
+	// dropping the connection resubscribes once reconnected
+	server.drop()
+	server.expect(t, `smd+265598+{"fields":["31","84"]}`)
+
+	server.send(t, `{"topic":"smd+265598","conid":265598,"31":"C193.50"}`)
+	select {
+	case update := <-sub.C:
+		assert.Equal(t, 193.5, update.Get(FieldLast).Value)
+		assert.True(t, update.Get(FieldLast).Closing)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for update")
+	}
+
+	assert.Nil(t, sub.Unsubscribe())
+	server.expect(t, `umd+265598+{}`)
+
+	_, ok := <-sub.C
+	assert.False(t, ok)
+}
+
+func TestStreamSubscribeWhileDisconnectedUnit(t *testing.T) {
+	oldReconnectInterval := streamReconnectInterval
+	streamReconnectInterval = 200 * time.Millisecond
+	defer func() { streamReconnectInterval = oldReconnectInterval }()
+
+	server := newTestStreamServer(t)
+	defer server.Close()
+
+	s := NewStream(server.url(), nil, nil)
+	defer s.Close()
+
+	assert.Nil(t, s.Connect())
+
+	server.drop()
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.conn == nil
+	}, 2*time.Second, 5*time.Millisecond)
+
+	// the dropped connection is not written to, the subscription is sent once reconnected
+	sub, err := s.SubscribeMarketData(265598, []SnapshotField{FieldLast})
+	assert.Nil(t, err)
+	server.expect(t, `smd+265598+{"fields":["31"]}`)
+
+	assert.Nil(t, sub.Unsubscribe())
+	server.expect(t, `umd+265598+{}`)
+}
+
+func TestStreamBufferUnit(t *testing.T) {
+	oldBufferSize := streamBufferSize
+	streamBufferSize = 2
+	defer func() { streamBufferSize = oldBufferSize }()
+
+	s := NewStream("ws://127.0.0.1:1", nil, nil)
+	sub, err := s.SubscribeMarketData(1, []SnapshotField{FieldLast})
+	assert.Nil(t, err)
+
+	for _, last := range []string{"1", "2", "3"} {
+		sub.handle([]byte(`{"topic":"smd+1","conid":1,"31":"` + last + `"}`))
+	}
+
+	// the oldest update is dropped
+	assert.Equal(t, 2.0, (<-sub.C).Get(FieldLast).Value)
+	assert.Equal(t, 3.0, (<-sub.C).Get(FieldLast).Value)
+
+	assert.Nil(t, s.Close())
+	_, ok := <-sub.C
+	assert.False(t, ok)
+
+	_, err = s.SubscribeMarketData(2, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,149 @@
+package ibweb
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultMaxReplies - most confirmations answered by SubmitOrders and SubmitModification when ReplyPolicy.MaxReplies is zero
+const DefaultMaxReplies = 10
+
+/*
+ReplyPolicy - Decides which order confirmations SubmitOrders and SubmitModification
+confirm. A reply is confirmed when all its message IDs are in ConfirmIDs,
+otherwise Confirm decides.
+*/
+type ReplyPolicy struct {
+	// ConfirmIDs - message IDs confirmed automatically, e.g. o163 for orders away from the last price
+	ConfirmIDs []string
+	// Confirm - called for replies not covered by ConfirmIDs, nil rejects them
+	Confirm func(reply PlaceOrders) bool
+	// MaxReplies - most replies sent before failing, zero uses DefaultMaxReplies
+	MaxReplies int
+}
+
+func (r ReplyPolicy) confirms(reply PlaceOrders) bool {
+	if len(reply.MessageIDs) > 0 {
+		confirmed := true
+		for _, id := range reply.MessageIDs {
+			if !containsString(r.ConfirmIDs, id) {
+				confirmed = false
+				break
+			}
+		}
+		if confirmed {
+			return true
+		}
+	}
+
+	if r.Confirm == nil {
+		return false
+	}
+
+	return r.Confirm(reply)
+}
+
+// OrderReplyMessage - Confirmation asked by the gateway and the answer sent
+type OrderReplyMessage struct {
+	ReplyID    string
+	Message    []string
+	MessageIDs []string
+	Confirmed  bool
+}
+
+// OrderSubmission - Orders accepted by the gateway and every confirmation answered on the way
+type OrderSubmission struct {
+	Orders     []PlaceOrders
+	Transcript []OrderReplyMessage
+}
+
+// OrderIDs - gets the IDs of the accepted orders
+func (o OrderSubmission) OrderIDs() []string {
+	ids := make([]string, 0, len(o.Orders))
+	for _, order := range o.Orders {
+		ids = append(ids, order.OrderID)
+	}
+
+	return ids
+}
+
+/*
+SubmitOrders - Places orders and answers the confirmations of the gateway with
+PlaceOrderReply according to policy until order IDs are returned. The submission
+is returned along with any error so the transcript can be audited, a rejected
+confirmation is answered before failing so the gateway discards the order.
+Prices are not rounded, pass the orders through RoundOrders first.
+*/
+func SubmitOrders(c Client, accountID string, input PlaceOrdersInput, policy ReplyPolicy) (*OrderSubmission, error) {
+	pending, err := c.PlaceOrders(accountID, input)
+	if err != nil {
+		return &OrderSubmission{}, errors.Wrap(err, "failed to place orders")
+	}
+
+	return replyOrders(c, pending, policy)
+}
+
+/*
+SubmitModification - Modifies a working order with ModifyOrder and answers the
+confirmations of the gateway like SubmitOrders
+*/
+func SubmitModification(c Client, accountID, orderID string, order Order, policy ReplyPolicy) (*OrderSubmission, error) {
+	pending, err := c.ModifyOrder(accountID, orderID, order)
+	if err != nil {
+		return &OrderSubmission{}, errors.Wrapf(err, "failed to modify order '%s'", orderID)
+	}
+
+	return replyOrders(c, pending, policy)
+}
+
+// replyOrders - answers the confirmations in pending according to policy until order IDs are returned
+func replyOrders(c Client, pending []PlaceOrders, policy ReplyPolicy) (*OrderSubmission, error) {
+	maxReplies := policy.MaxReplies
+	if maxReplies <= 0 {
+		maxReplies = DefaultMaxReplies
+	}
+
+	submission := &OrderSubmission{}
+
+	replies := 0
+	for len(pending) > 0 {
+		reply := pending[0]
+		pending = pending[1:]
+
+		if reply.OrderID != "" {
+			submission.Orders = append(submission.Orders, reply)
+			continue
+		}
+
+		if reply.ID == "" {
+			return submission, errors.New("gateway returned neither an order ID nor a reply ID")
+		}
+
+		if replies >= maxReplies {
+			return submission, errors.Errorf("orders not accepted after %d replies", maxReplies)
+		}
+		replies++
+
+		confirmed := policy.confirms(reply)
+		submission.Transcript = append(submission.Transcript, OrderReplyMessage{
+			ReplyID:    reply.ID,
+			Message:    reply.Message,
+			MessageIDs: reply.MessageIDs,
+			Confirmed:  confirmed,
+		})
+
+		next, err := c.PlaceOrderReply(reply.ID, PlaceOrderReplyInput{Confirmed: confirmed})
+		if err != nil {
+			return submission, errors.Wrapf(err, "failed to reply to '%s'", reply.ID)
+		}
+
+		if !confirmed {
+			return submission, errors.Errorf("rejected confirmation '%s': %s", reply.ID, strings.Join(reply.Message, "; "))
+		}
+
+		pending = append(pending, next...)
+	}
+
+	return submission, nil
+}
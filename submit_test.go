@@ -0,0 +1,186 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmitOrdersUnit(t *testing.T) {
+	placed := []PlaceOrders{{
+		ID:         "reply-1",
+		Message:    []string{"The price is more than 3% away from the last price."},
+		MessageIDs: []string{"o163"},
+	}}
+	replies := map[string][]PlaceOrders{
+		"reply-1": {{
+			ID:         "reply-2",
+			Message:    []string{"You are submitting an order without market data."},
+			MessageIDs: []string{"o354"},
+		}},
+		"reply-2": {{OrderID: "1792085150", OrderStatus: "Submitted"}},
+	}
+
+	tests := []struct {
+		name            string
+		policy          ReplyPolicy
+		wantErrContains string
+		wantOrderIDs    []string
+		wantTranscript  []OrderReplyMessage
+	}{
+		{
+			"confirms allowlisted message IDs",
+			ReplyPolicy{ConfirmIDs: []string{"o163", "o354"}},
+			"",
+			[]string{"1792085150"},
+			[]OrderReplyMessage{
+				{ReplyID: "reply-1", Message: placed[0].Message, MessageIDs: []string{"o163"}, Confirmed: true},
+				{ReplyID: "reply-2", Message: replies["reply-1"][0].Message, MessageIDs: []string{"o354"}, Confirmed: true},
+			},
+		},
+		{
+			"rejects messages not allowlisted",
+			ReplyPolicy{ConfirmIDs: []string{"o163"}},
+			"rejected confirmation 'reply-2': You are submitting an order without market data.",
+			nil,
+			[]OrderReplyMessage{
+				{ReplyID: "reply-1", Message: placed[0].Message, MessageIDs: []string{"o163"}, Confirmed: true},
+				{ReplyID: "reply-2", Message: replies["reply-1"][0].Message, MessageIDs: []string{"o354"}, Confirmed: false},
+			},
+		},
+		{
+			"consults the confirm func",
+			ReplyPolicy{ConfirmIDs: []string{"o163"}, Confirm: func(reply PlaceOrders) bool { return reply.ID == "reply-2" }},
+			"",
+			[]string{"1792085150"},
+			[]OrderReplyMessage{
+				{ReplyID: "reply-1", Message: placed[0].Message, MessageIDs: []string{"o163"}, Confirmed: true},
+				{ReplyID: "reply-2", Message: replies["reply-1"][0].Message, MessageIDs: []string{"o354"}, Confirmed: true},
+			},
+		},
+		{
+			"caps replies",
+			ReplyPolicy{ConfirmIDs: []string{"o163", "o354"}, MaxReplies: 1},
+			"orders not accepted after 1 replies",
+			nil,
+			[]OrderReplyMessage{
+				{ReplyID: "reply-1", Message: placed[0].Message, MessageIDs: []string{"o163"}, Confirmed: true},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = io.ReadAll
+
+		var answers []PlaceOrderReplyInput
+		httpmock.RegisterResponder(http.MethodPost,
+			fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(placeOrdersPath, param{key: "accountId", value: "DU123456"})),
+			httpmock.NewJsonResponderOrPanic(200, placed))
+		for id, resp := range replies {
+			resp := resp
+			httpmock.RegisterResponder(http.MethodPost,
+				fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(placeOrderReplyPath, param{key: "replyid", value: id})),
+				func(req *http.Request) (*http.Response, error) {
+					var answer PlaceOrderReplyInput
+					if err := json.NewDecoder(req.Body).Decode(&answer); err != nil {
+						return nil, err
+					}
+					answers = append(answers, answer)
+
+					return httpmock.NewJsonResponse(200, resp)
+				})
+		}
+
+		c := New("http://127.0.0.1:5555")
+		submission, err := SubmitOrders(c, "DU123456", PlaceOrdersInput{Orders: []Order{{Conid: 265598, OrderType: Limit, Side: Buy, Price: 187.25, Quantity: 1}}}, tc.policy)
+		assertError(t, tc.wantErrContains != "", tc.wantErrContains, err)
+		if assert.NotNil(t, submission, tc.name) {
+			assert.ElementsMatch(t, tc.wantOrderIDs, submission.OrderIDs(), tc.name)
+			assert.Equal(t, tc.wantTranscript, submission.Transcript, tc.name)
+		}
+
+		// every answer sent matches the transcript, rejections included
+		if assert.Len(t, answers, len(tc.wantTranscript), tc.name) {
+			for i, answer := range answers {
+				assert.Equal(t, tc.wantTranscript[i].Confirmed, answer.Confirmed, tc.name)
+			}
+		}
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestSubmitOrdersFailureUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	httpmock.RegisterResponder(http.MethodPost,
+		fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(placeOrdersPath, param{key: "accountId", value: "DU123456"})),
+		httpmock.NewStringResponder(500, "failed"))
+
+	c := New("http://127.0.0.1:5555")
+	_, err := SubmitOrders(c, "DU123456", PlaceOrdersInput{}, ReplyPolicy{})
+	assertError(t, true, "failed to place orders", err)
+
+	httpmock.RegisterResponder(http.MethodPost,
+		fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(placeOrdersPath, param{key: "accountId", value: "DU123456"})),
+		httpmock.NewStringResponder(200, `[{"order_status":"Submitted"}]`))
+
+	_, err = SubmitOrders(c, "DU123456", PlaceOrdersInput{}, ReplyPolicy{})
+	assertError(t, true, "neither an order ID nor a reply ID", err)
+}
+
+func TestSubmitModificationUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	modifyURL := fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(modifyOrderPath,
+		param{key: "accountId", value: "DU123456"}, param{key: "orderId", value: "1792085150"}))
+
+	var modified Order
+	httpmock.RegisterResponder(http.MethodPost, modifyURL,
+		func(req *http.Request) (*http.Response, error) {
+			if err := json.NewDecoder(req.Body).Decode(&modified); err != nil {
+				return nil, err
+			}
+
+			return httpmock.NewJsonResponse(200, []PlaceOrders{{
+				ID:         "reply-1",
+				Message:    []string{"The price is more than 3% away from the last price."},
+				MessageIDs: []string{"o163"},
+			}})
+		})
+	httpmock.RegisterResponder(http.MethodPost,
+		fmt.Sprintf("http://127.0.0.1:5555/%s", substituteParam(placeOrderReplyPath, param{key: "replyid", value: "reply-1"})),
+		httpmock.NewJsonResponderOrPanic(200, []PlaceOrders{{OrderID: "1792085150", OrderStatus: "PreSubmitted"}}))
+
+	c := New("http://127.0.0.1:5555")
+	order := Order{Conid: 265598, OrderType: Limit, Side: Buy, Price: 180, Quantity: 1}
+
+	submission, err := SubmitModification(c, "DU123456", "1792085150", order, ReplyPolicy{ConfirmIDs: []string{"o163"}})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, order, modified)
+	assert.Equal(t, []string{"1792085150"}, submission.OrderIDs())
+	assert.Equal(t, []OrderReplyMessage{
+		{ReplyID: "reply-1", Message: []string{"The price is more than 3% away from the last price."}, MessageIDs: []string{"o163"}, Confirmed: true},
+	}, submission.Transcript)
+
+	submission, err = SubmitModification(c, "DU123456", "1792085150", order, ReplyPolicy{})
+	assertError(t, true, "rejected confirmation 'reply-1'", err)
+	assert.Empty(t, submission.OrderIDs())
+	assert.Len(t, submission.Transcript, 1)
+
+	httpmock.RegisterResponder(http.MethodPost, modifyURL, httpmock.NewStringResponder(500, "failed"))
+	_, err = SubmitModification(c, "DU123456", "1792085150", order, ReplyPolicy{})
+	assertError(t, true, "failed to modify order '1792085150'", err)
+}
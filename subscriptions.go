@@ -0,0 +1,240 @@
+package ibweb
+
+import (
+	"container/list"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	marketDataUnsubscribePath    = "v1/api/iserver/marketdata/unsubscribe"
+	marketDataUnsubscribeAllPath = "v1/api/iserver/marketdata/unsubscribeall"
+
+	// DefaultMarketDataLines - concurrent market data lines of an account without boosts
+	DefaultMarketDataLines = 100
+)
+
+/*
+UnsubscribeMarketData -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1iserver~1marketdata~1unsubscribe/post
+*/
+type UnsubscribeMarketData struct {
+	Success bool `json:"success"`
+}
+
+/*
+UnsubscribeAllMarketData -
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1iserver~1marketdata~1unsubscribeall/get
+*/
+type UnsubscribeAllMarketData struct {
+	Unsubscribed bool `json:"unsubscribed"`
+}
+
+/*
+SubscriptionManager - Tracks the conids holding market data lines and keeps them
+within a budget by unsubscribing the least recently used conids. Every snapshot
+or stream subscription should Acquire its conids first.
+*/
+type SubscriptionManager struct {
+	// OnEvict - called with each conid unsubscribed to free a line, e.g. to
+	// unsubscribe its stream
+	OnEvict func(conid int)
+
+	client Client
+	budget int
+
+	mu     sync.Mutex
+	lru    *list.List
+	active map[int]*list.Element
+}
+
+// NewSubscriptionManager - returns a SubscriptionManager allowing budget lines, zero uses DefaultMarketDataLines
+func NewSubscriptionManager(c Client, budget int) *SubscriptionManager {
+	if budget <= 0 {
+		budget = DefaultMarketDataLines
+	}
+
+	return &SubscriptionManager{
+		client: c,
+		budget: budget,
+		lru:    list.New(),
+		active: map[int]*list.Element{},
+	}
+}
+
+/*
+Acquire - Marks conids as in use, unsubscribing the least recently used conids
+when the budget is exceeded. Returns the evicted conids.
+*/
+func (m *SubscriptionManager) Acquire(conids ...int) ([]int, error) {
+	unique := map[int]bool{}
+	for _, conid := range conids {
+		unique[conid] = true
+	}
+	if len(unique) > m.budget {
+		return nil, errors.Errorf("%d conids exceed the budget of %d market data lines", len(unique), m.budget)
+	}
+
+	m.mu.Lock()
+	for _, conid := range conids {
+		if e, ok := m.active[conid]; ok {
+			m.lru.MoveToFront(e)
+			continue
+		}
+		m.active[conid] = m.lru.PushFront(conid)
+	}
+
+	var evicted []int
+	for m.lru.Len() > m.budget {
+		e := m.lru.Back()
+		conid := e.Value.(int)
+		m.lru.Remove(e)
+		delete(m.active, conid)
+		evicted = append(evicted, conid)
+	}
+	m.mu.Unlock()
+
+	for _, conid := range evicted {
+		if err := m.unsubscribe(conid); err != nil {
+			return evicted, err
+		}
+	}
+
+	return evicted, nil
+}
+
+// Release - unsubscribes a conid and frees its line
+func (m *SubscriptionManager) Release(conid int) error {
+	m.mu.Lock()
+	e, ok := m.active[conid]
+	if ok {
+		m.lru.Remove(e)
+		delete(m.active, conid)
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	return m.unsubscribe(conid)
+}
+
+// Snapshot - acquires lines for conids and gets their snapshot
+func (m *SubscriptionManager) Snapshot(conids []int, fields []SnapshotField) ([]Snapshot, error) {
+	if _, err := m.Acquire(conids...); err != nil {
+		return nil, err
+	}
+
+	return m.client.Snapshot(conids, fields)
+}
+
+// Active - gets the conids holding lines, most recently used first
+func (m *SubscriptionManager) Active() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	conids := make([]int, 0, m.lru.Len())
+	for e := m.lru.Front(); e != nil; e = e.Next() {
+		conids = append(conids, e.Value.(int))
+	}
+
+	return conids
+}
+
+// Close - unsubscribes every market data subscription of the session
+func (m *SubscriptionManager) Close() error {
+	m.mu.Lock()
+	conids := make([]int, 0, len(m.active))
+	for conid := range m.active {
+		conids = append(conids, conid)
+	}
+	m.lru.Init()
+	m.active = map[int]*list.Element{}
+	m.mu.Unlock()
+
+	if m.OnEvict != nil {
+		for _, conid := range conids {
+			m.OnEvict(conid)
+		}
+	}
+
+	if _, err := m.client.UnsubscribeAllMarketData(); err != nil {
+		return errors.Wrap(err, "failed to unsubscribe all market data")
+	}
+
+	return nil
+}
+
+func (m *SubscriptionManager) unsubscribe(conid int) error {
+	if m.OnEvict != nil {
+		m.OnEvict(conid)
+	}
+
+	if _, err := m.client.UnsubscribeMarketData(conid); err != nil {
+		return errors.Wrapf(err, "failed to unsubscribe market data of conid '%d'", conid)
+	}
+
+	return nil
+}
+
+/*
+UnsubscribeMarketData - Cancels the market data subscription of a contract
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1iserver~1marketdata~1unsubscribe/post
+*/
+func (c *client) UnsubscribeMarketData(conid int) (*UnsubscribeMarketData, error) {
+	resp, err := c.post(marketDataUnsubscribePath, struct {
+		Conid int `json:"conid"`
+	}{conid})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var unsubscribe UnsubscribeMarketData
+	if err := json.Unmarshal(v, &unsubscribe); err != nil {
+		return nil, err
+	}
+
+	return &unsubscribe, nil
+}
+
+/*
+UnsubscribeAllMarketData - Cancels every market data subscription of the session
+Link: https://www.interactivebrokers.com/api/doc.html#tag/Market-Data/paths/~1iserver~1marketdata~1unsubscribeall/get
+*/
+func (c *client) UnsubscribeAllMarketData() (*UnsubscribeAllMarketData, error) {
+	resp, err := c.get(marketDataUnsubscribeAllPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, StatusCodeError{StatusCode: resp.StatusCode, Err: NewIBError(resp)}
+	}
+
+	defer resp.Body.Close()
+	v, err := readAllFn(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var unsubscribeAll UnsubscribeAllMarketData
+	if err := json.Unmarshal(v, &unsubscribeAll); err != nil {
+		return nil, err
+	}
+
+	return &unsubscribeAll, nil
+}
@@ -0,0 +1,278 @@
+package ibweb
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnsubscribeMarketDataUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to post",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to post unsubscribe")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to post unsubscribe",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read unsubscribe")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read unsubscribe",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/unsubscribe_market_data.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataUnsubscribePath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.UnsubscribeMarketData(265598)
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestUnsubscribeAllMarketDataUnit(t *testing.T) {
+	type input struct {
+		handler   func(req *http.Request) (*http.Response, error)
+		readAllFn func(r io.Reader) ([]byte, error)
+	}
+
+	type want struct {
+		wantErr         bool
+		wantErrContains string
+	}
+
+	tests := []struct {
+		name  string
+		input input
+		want  want
+	}{
+		{
+			"handles failure to get",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return nil, errors.New("failed to get unsubscribe all")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to get unsubscribe all",
+			},
+		},
+		{
+			"handles unexpected status code",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(500, "failed"), nil
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid status code",
+			},
+		},
+		{
+			"handles failure to read response body",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, ""), nil
+				},
+				readAllFn: func(r io.Reader) ([]byte, error) {
+					return nil, errors.New("failed to read unsubscribe all")
+				},
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "failed to read unsubscribe all",
+			},
+		},
+		{
+			"handles failure to unmarshal response",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					return httpmock.NewStringResponse(200, "garbage"), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr:         true,
+				wantErrContains: "invalid character",
+			},
+		},
+		{
+			"is successful",
+			input{
+				handler: func(req *http.Request) (*http.Response, error) {
+					v, err := os.ReadFile("./testdata/unsubscribe_all_market_data.json")
+					if !assert.Nil(t, err) {
+						t.FailNow()
+					}
+
+					return httpmock.NewBytesResponse(200, v), nil
+				},
+				readAllFn: io.ReadAll,
+			},
+			want{
+				wantErr: false,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		httpmock.Activate()
+		readAllFn = tc.input.readAllFn
+
+		httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataUnsubscribeAllPath), tc.input.handler)
+
+		c := New("http://127.0.0.1:5555")
+		_, err := c.UnsubscribeAllMarketData()
+		assertError(t, tc.want.wantErr, tc.want.wantErrContains, err)
+
+		httpmock.DeactivateAndReset()
+	}
+}
+
+func TestSubscriptionManagerUnit(t *testing.T) {
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+	readAllFn = io.ReadAll
+
+	var unsubscribed []int
+	httpmock.RegisterResponder(http.MethodPost, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataUnsubscribePath),
+		func(req *http.Request) (*http.Response, error) {
+			var body struct {
+				Conid int `json:"conid"`
+			}
+			if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+				return httpmock.NewStringResponse(400, "bad request"), nil
+			}
+			unsubscribed = append(unsubscribed, body.Conid)
+			return httpmock.NewStringResponse(200, `{"success":true}`), nil
+		})
+
+	unsubscribeAllURL := fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataUnsubscribeAllPath)
+	httpmock.RegisterResponder(http.MethodGet, unsubscribeAllURL,
+		httpmock.NewStringResponder(200, `{"unsubscribed":true}`))
+
+	httpmock.RegisterResponder(http.MethodGet, fmt.Sprintf("http://127.0.0.1:5555/%s", marketDataSnapshot),
+		func(req *http.Request) (*http.Response, error) {
+			return httpmock.NewStringResponse(200, `[{"conid":4,"31":"1.5"}]`), nil
+		})
+
+	var evicted []int
+	m := NewSubscriptionManager(New("http://127.0.0.1:5555"), 3)
+	m.OnEvict = func(conid int) { evicted = append(evicted, conid) }
+
+	got, err := m.Acquire(1, 2, 3)
+	assert.Nil(t, err)
+	assert.Empty(t, got)
+
+	// using 1 again makes 2 the least recently used
+	_, err = m.Acquire(1)
+	assert.Nil(t, err)
+
+	snapshots, err := m.Snapshot([]int{4}, []SnapshotField{FieldLast})
+	assert.Nil(t, err)
+	assert.Len(t, snapshots, 1)
+	assert.Equal(t, []int{4, 1, 3}, m.Active())
+	assert.Equal(t, []int{2}, unsubscribed)
+	assert.Equal(t, []int{2}, evicted)
+
+	assert.Nil(t, m.Release(3))
+	assert.Nil(t, m.Release(3))
+	assert.Equal(t, []int{4, 1}, m.Active())
+	assert.Equal(t, []int{2, 3}, unsubscribed)
+
+	_, err = m.Acquire(5, 6, 7, 8)
+	assertError(t, true, "exceed the budget", err)
+
+	assert.Nil(t, m.Close())
+	assert.Empty(t, m.Active())
+	assert.ElementsMatch(t, []int{2, 3, 4, 1}, evicted)
+	assert.Equal(t, 1, httpmock.GetCallCountInfo()[http.MethodGet+" "+unsubscribeAllURL])
+
+	assert.Equal(t, DefaultMarketDataLines, NewSubscriptionManager(nil, 0).budget)
+}